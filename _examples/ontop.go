@@ -19,6 +19,7 @@ func main() {
 	}
 	defer g.Close()
 
+	g.Mouse = true
 	g.SetManagerFunc(layout)
 
 	if err := keybindings(g); err != nil {
@@ -35,18 +36,24 @@ func layout(g *gocui.Gui) error {
 		if !errors.Is(err, gocui.ErrUnknownView) {
 			return err
 		}
+		v.Draggable = true
+		v.Resizable = true
 		fmt.Fprintln(v, "View #1")
 	}
 	if v, err := g.SetView("v2", 20, 4, 40, 8, 0); err != nil {
 		if !errors.Is(err, gocui.ErrUnknownView) {
 			return err
 		}
+		v.Draggable = true
+		v.Resizable = true
 		fmt.Fprintln(v, "View #2")
 	}
 	if v, err := g.SetView("v3", 30, 6, 50, 10, 0); err != nil {
 		if !errors.Is(err, gocui.ErrUnknownView) {
 			return err
 		}
+		v.Draggable = true
+		v.Resizable = true
 		fmt.Fprintln(v, "View #3")
 		if _, err := g.SetCurrentView("v3"); err != nil {
 			return err
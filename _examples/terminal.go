@@ -0,0 +1,60 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+func main() {
+	g, err := gocui.NewGui(gocui.Output256, true)
+	if err != nil {
+		log.Panicln(err)
+	}
+	defer g.Close()
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "sh"
+	}
+	tv := gocui.NewTerminalView("terminal", exec.Command(shell))
+	defer tv.Close()
+	tv.OnExit = func(g *gocui.Gui, v *gocui.View, err error) error {
+		return gocui.ErrQuit
+	}
+
+	g.Cursor = true
+	g.SetManagerFunc(func(g *gocui.Gui) error {
+		maxX, maxY := g.Size()
+		v, err := tv.SetView(g, 0, 0, maxX-1, maxY-1)
+		if err != nil {
+			if !errors.Is(err, gocui.ErrUnknownView) {
+				return err
+			}
+			v.Title = "Terminal (Ctrl-Q to quit)"
+			if _, err := g.SetCurrentView("terminal"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err := g.SetKeybinding("", gocui.KeyCtrlQ, gocui.ModNone, quit); err != nil {
+		log.Panicln(err)
+	}
+
+	if err := g.MainLoop(); err != nil && !errors.Is(err, gocui.ErrQuit) {
+		log.Panicln(err)
+	}
+}
+
+func quit(g *gocui.Gui, v *gocui.View) error {
+	return gocui.ErrQuit
+}
@@ -4,7 +4,12 @@
 
 package gocui
 
-import "github.com/gdamore/tcell/v2"
+import (
+	"math"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
 
 // Attribute affects the presentation of characters, such as color, boldness, etc.
 type Attribute uint64
@@ -120,6 +125,128 @@ func NewRGBColor(r, g, b int32) Attribute {
 	return Attribute(tcell.NewRGBColor(r, g, b))
 }
 
+// oklab is a color in the Oklab perceptual color space, where the euclidean
+// distance between two colors matches their perceived difference.
+type oklab struct {
+	l, a, b float64
+}
+
+// rgbToOklab converts an sRGB color to Oklab.
+func rgbToOklab(r, g, b int32) oklab {
+	linear := func(c int32) float64 {
+		v := float64(c) / 255
+		if v <= 0.04045 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	lr, lg, lb := linear(r), linear(g), linear(b)
+
+	l := math.Cbrt(0.4122214708*lr + 0.5363325363*lg + 0.0514459929*lb)
+	m := math.Cbrt(0.2119034982*lr + 0.6806995451*lg + 0.1073969566*lb)
+	s := math.Cbrt(0.0883024619*lr + 0.2817188376*lg + 0.6299787005*lb)
+
+	return oklab{
+		l: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		a: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		b: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// distance returns the squared distance between two colors.
+func (c oklab) distance(o oklab) float64 {
+	dl, da, db := c.l-o.l, c.a-o.a, c.b-o.b
+	return dl*dl + da*da + db*db
+}
+
+// paletteOklab holds the Oklab values of the 256 colors of the xterm palette.
+var paletteOklab = func() (p [256]oklab) {
+	for i := range p {
+		r, g, b := tcell.PaletteColor(i).RGB()
+		p[i] = rgbToOklab(r, g, b)
+	}
+	return p
+}()
+
+// nearestCacheSize is the maximum number of colors in nearestCache, which is
+// emptied when it is full.
+const nearestCacheSize = 4096
+
+var (
+	nearestMutex sync.Mutex
+	nearestCache = make(map[uint64]int, nearestCacheSize)
+)
+
+// nearestPaletteColor returns the index, between first and last, of the color
+// of the xterm palette which is perceptually the closest to the given RGB
+// color. If lightness is true, only the lightness of the colors is compared.
+// The colors looked up last are cached, as the same colors are usually drawn
+// in many cells.
+func nearestPaletteColor(r, g, b int32, first, last int, lightness bool) int {
+	key := uint64(r&0xff)<<16 | uint64(g&0xff)<<8 | uint64(b&0xff) | uint64(first)<<24 | uint64(last)<<32
+	if lightness {
+		key |= 1 << 40
+	}
+	nearestMutex.Lock()
+	i, ok := nearestCache[key]
+	nearestMutex.Unlock()
+	if ok {
+		return i
+	}
+
+	i = findNearestPaletteColor(r, g, b, first, last, lightness)
+	nearestMutex.Lock()
+	if len(nearestCache) >= nearestCacheSize {
+		nearestCache = make(map[uint64]int, nearestCacheSize)
+	}
+	nearestCache[key] = i
+	nearestMutex.Unlock()
+	return i
+}
+
+// findNearestPaletteColor is nearestPaletteColor without the cache.
+func findNearestPaletteColor(r, g, b int32, first, last int, lightness bool) int {
+	c := rgbToOklab(r, g, b)
+	if lightness {
+		c.a, c.b = 0, 0
+	}
+	best, bestDist := first, math.Inf(1)
+	for i := first; i <= last; i++ {
+		p := paletteOklab[i]
+		if lightness {
+			p.a, p.b = 0, 0
+		}
+		if d := c.distance(p); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// rgbTo216 returns the index (0-215) of the color of the 6x6x6 color cube
+// which is closest to the given RGB color.
+func rgbTo216(r, g, b int32) int {
+	return nearestPaletteColor(r, g, b, 16, 231, false) - 16
+}
+
+// rgbToGrayscale returns the index in grayscale of the shade of grey which
+// is closest to the lightness of the given RGB color.
+func rgbToGrayscale(r, g, b int32) int {
+	n := nearestPaletteColor(r, g, b, 232, 255, true)
+	// black and white are the first and last entries of grayscale, they
+	// are compared separately as they are not part of the 232-255 ramp
+	best := n - 231
+	c := rgbToOklab(r, g, b)
+	ramp := math.Abs(c.l - paletteOklab[n].l)
+	if black := math.Abs(c.l - paletteOklab[16].l); black < ramp {
+		best, ramp = 0, black
+	}
+	if white := math.Abs(c.l - paletteOklab[231].l); white < ramp {
+		best = len(grayscale) - 1
+	}
+	return best
+}
+
 // getTcellColor transform  Attribute into tcell.Color
 func getTcellColor(c Attribute, omode OutputMode) tcell.Color {
 	c = c & AttrColorBits
@@ -139,26 +266,52 @@ func getTcellColor(c Attribute, omode OutputMode) tcell.Color {
 		tc = tcell.Color(c-1) | tcell.ColorValid
 	}
 
+	// RGB colors, and palette colors which are not available in the output
+	// mode, are mapped to the perceptually nearest color of the palette
+	var r, g, b int32
+	isRGB := tc&tcell.ColorIsRGB != 0
+	if isRGB {
+		r, g, b = tc.RGB()
+	}
+
 	switch omode {
 	case OutputTrue:
 		return tc
 	case OutputNormal:
-		tc &= tcell.Color(0xf) | tcell.ColorValid
+		if !isRGB && tc&^tcell.ColorValid > 15 {
+			r, g, b = tc.RGB()
+			isRGB = true
+		}
+		if isRGB {
+			return tcell.PaletteColor(nearestPaletteColor(r, g, b, 0, 15, false))
+		}
 	case Output256:
+		// the 16 first colors are often redefined by terminal themes, so
+		// they are not used for RGB colors
+		if isRGB {
+			return tcell.PaletteColor(nearestPaletteColor(r, g, b, 16, 255, false))
+		}
 		tc &= tcell.Color(0xff) | tcell.ColorValid
 	case Output216:
+		if isRGB {
+			return tcell.PaletteColor(16 + rgbTo216(r, g, b))
+		}
 		tc &= tcell.Color(0xff)
 		if tc > 215 {
 			return tcell.ColorDefault
 		}
 		tc += tcell.Color(16) | tcell.ColorValid
 	case OutputGrayscale:
+		if isRGB {
+			return grayscale[rgbToGrayscale(r, g, b)] | tcell.ColorValid
+		}
 		tc &= tcell.Color(0x1f)
 		if tc > 26 {
 			return tcell.ColorDefault
 		}
 		tc = grayscale[tc] | tcell.ColorValid
 	default:
+		// e.g. OutputNoColor, only the attributes are drawn
 		return tcell.ColorDefault
 	}
 	return tc
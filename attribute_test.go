@@ -0,0 +1,51 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestGetTcellColorDownsampling(t *testing.T) {
+	tests := []struct {
+		name  string
+		color Attribute
+		mode  OutputMode
+		want  tcell.Color
+	}{
+		{"rgb in true", NewRGBColor(0x12, 0x34, 0x56), OutputTrue, tcell.NewRGBColor(0x12, 0x34, 0x56)},
+		{"red in normal", NewRGBColor(0xff, 0x10, 0x10), OutputNormal, tcell.ColorRed},
+		{"dark red in normal", NewRGBColor(0x80, 0, 0), OutputNormal, tcell.ColorMaroon},
+		{"palette in normal", Get256Color(196), OutputNormal, tcell.ColorRed},
+		{"orange in 256", NewRGBColor(0xff, 0x87, 0), Output256, tcell.PaletteColor(208)},
+		{"near orange in 256", NewRGBColor(0xfa, 0x88, 0x05), Output256, tcell.PaletteColor(208)},
+		{"palette in 256", Get256Color(100), Output256, tcell.PaletteColor(100)},
+		{"rgb in 216", NewRGBColor(0, 0, 0xff), Output216, tcell.PaletteColor(21)},
+		{"rgb in grayscale", NewRGBColor(0xff, 0xff, 0xff), OutputGrayscale, tcell.PaletteColor(231)},
+		{"dark rgb in grayscale", NewRGBColor(0x30, 0x30, 0x30), OutputGrayscale, tcell.PaletteColor(236)},
+	}
+
+	for _, test := range tests {
+		if got := getTcellColor(test.color, test.mode); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNearestPaletteColorCache(t *testing.T) {
+	for r := int32(0); r < 256; r += 3 {
+		for g := int32(0); g < 256; g += 5 {
+			b := (r + g) % 256
+			if got, want := nearestPaletteColor(r, g, b, 16, 255, false), findNearestPaletteColor(r, g, b, 16, 255, false); got != want {
+				t.Fatalf("got %d for %d,%d,%d, want %d", got, r, g, b, want)
+			}
+		}
+	}
+	if n := len(nearestCache); n > nearestCacheSize {
+		t.Errorf("got %d cached colors, want at most %d", n, nearestCacheSize)
+	}
+}
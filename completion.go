@@ -0,0 +1,294 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// completionViewName is the name of the view of the completion popup.
+const completionViewName = "gocui.completion"
+
+// completionMaxHeight is the maximum number of candidates displayed at once
+// in the completion popup.
+const completionMaxHeight = 10
+
+// Completion is a candidate proposed by a Completer.
+type Completion struct {
+	// Text replaces the text from the start returned by the Completer to
+	// the cursor.
+	Text string
+
+	// Display is the text shown in the popup, Text if it is empty.
+	Display string
+
+	// Description is shown after the display text, with the muted style.
+	Description string
+}
+
+// Completer interface must be satisfied by the completers of editable views.
+type Completer interface {
+	// Complete returns the candidates for the buffer of a view with the
+	// cursor on the line y, before the byte x of the line, and the byte of
+	// the line where the text they replace starts. Offsets inside of a
+	// grapheme cluster are moved to its start.
+	Complete(buffer string, x, y int) (candidates []Completion, start int)
+}
+
+// The CompleterFunc type is an adapter to allow the use of ordinary
+// functions as Completers. If f is a function with the appropriate
+// signature, CompleterFunc(f) is a Completer object that calls f.
+type CompleterFunc func(buffer string, x, y int) ([]Completion, int)
+
+// Complete calls f(buffer, x, y)
+func (f CompleterFunc) Complete(buffer string, x, y int) ([]Completion, int) {
+	return f(buffer, x, y)
+}
+
+// completion is the state of the completion popup of a view.
+type completion struct {
+	v          *View
+	candidates []Completion
+
+	// start of the text replaced by the candidates, on line y
+	start, y int
+
+	// candidates matching the text typed since the popup opened, the
+	// selected one and the first one displayed
+	filtered []Completion
+	index    int
+	top      int
+}
+
+// completionKey handles the keys navigating the completion popup of the
+// current view while it is open. It returns true if the key was handled.
+func (g *Gui) completionKey(ev *gocuiEvent) (bool, error) {
+	v := g.currentView
+	key := Key(ev.Key)
+	c := g.completion
+	if c != nil && c.v != v {
+		// the focus moved to another view
+		if err := g.closeCompletion(); err != nil {
+			return false, err
+		}
+		c = nil
+	}
+	if c == nil {
+		return false, nil
+	}
+
+	switch key {
+	case KeyTab, KeyArrowDown, KeyCtrlN:
+		c.move(1)
+	case KeyBacktab, KeyArrowUp, KeyCtrlP:
+		c.move(-1)
+	case KeyEnter:
+		return true, g.acceptCompletion()
+	case KeyEsc:
+		return true, g.closeCompletion()
+	default:
+		return false, nil
+	}
+	return true, g.renderCompletion()
+}
+
+// tabCompletion opens the completion popup of the current view if the key
+// is Tab and the view has a completer. It is called for the keys which
+// didn't match a keybinding, and returns true if the key was handled.
+func (g *Gui) tabCompletion(ev *gocuiEvent) (bool, error) {
+	v := g.currentView
+	if Key(ev.Key) != KeyTab || v == nil || !v.Editable || v.Editor == nil || v.Completer == nil {
+		return false, nil
+	}
+	return g.openCompletion(v, true)
+}
+
+// openCompletion asks the completer of the view for candidates and shows
+// them in the popup. If single is true, a single candidate is inserted right
+// away. It returns false if there is no candidate.
+func (g *Gui) openCompletion(v *View, single bool) (bool, error) {
+	var line []cell
+	if v.cy < len(v.lines) {
+		line = v.lines[v.cy]
+	}
+	_, starts := lineText(line)
+	x := starts[min(v.cx, len(line))]
+	candidates, byteStart := v.Completer.Complete(v.Buffer(), x, v.cy)
+	if len(candidates) == 0 || byteStart < 0 || byteStart > x {
+		return false, nil
+	}
+	start, _ := cellSpan(starts, byteStart, byteStart)
+	g.completion = &completion{v: v, candidates: candidates, start: start, y: v.cy}
+	g.completion.filter()
+	if len(g.completion.filtered) == 0 {
+		g.completion = nil
+		return false, nil
+	}
+	if single && len(g.completion.filtered) == 1 {
+		return true, g.acceptCompletion()
+	}
+	return true, g.renderCompletion()
+}
+
+// updateCompletion filters the candidates of the popup with the text typed
+// in the view since it opened, and closes it if the cursor left the
+// completed text. If the view has AutoComplete set, the popup is opened
+// when a character is typed.
+func (g *Gui) updateCompletion(v *View, ch rune) error {
+	c := g.completion
+	if c == nil || c.v != v {
+		if ch == 0 || !v.AutoComplete || v.Completer == nil {
+			return nil
+		}
+		_, err := g.openCompletion(v, false)
+		return err
+	}
+	if v.cy != c.y || v.cx < c.start {
+		return g.closeCompletion()
+	}
+	c.filter()
+	if len(c.filtered) == 0 {
+		return g.closeCompletion()
+	}
+	return g.renderCompletion()
+}
+
+// filter keeps the candidates starting with the text between the start of
+// the completion and the cursor, ignoring the case.
+func (c *completion) filter() {
+	typed := strings.ToLower(c.typed())
+	c.filtered = c.filtered[:0]
+	for _, cand := range c.candidates {
+		if strings.HasPrefix(strings.ToLower(cand.Text), typed) {
+			c.filtered = append(c.filtered, cand)
+		}
+	}
+	c.index, c.top = 0, 0
+}
+
+// typed returns the text between the start of the completion and the cursor.
+func (c *completion) typed() string {
+	v := c.v
+	if c.y >= len(v.lines) {
+		return ""
+	}
+	line := v.lines[c.y]
+	return lineType(line[min(c.start, len(line)):min(v.cx, len(line))]).String()
+}
+
+// move selects the candidate delta positions after the selected one, going
+// around at both ends of the list.
+func (c *completion) move(delta int) {
+	n := len(c.filtered)
+	c.index = (c.index + delta + n) % n
+	if c.index < c.top {
+		c.top = c.index
+	} else if c.index >= c.top+completionMaxHeight {
+		c.top = c.index - completionMaxHeight + 1
+	}
+}
+
+// acceptCompletion replaces the typed text by the selected candidate and
+// closes the popup. The buffer is edited directly, in a single undo step,
+// whatever the editor of the view.
+func (g *Gui) acceptCompletion() error {
+	c := g.completion
+	v := c.v
+	text := c.filtered[c.index].Text
+	if err := g.closeCompletion(); err != nil {
+		return err
+	}
+
+	v.edit(editOther, c.y, c.y, func() {
+		v.deleteRange(bufferPos{c.start, c.y}, bufferPos{v.cx, v.cy})
+		v.insertText(text)
+	})
+	return nil
+}
+
+// closeCompletion hides the completion popup.
+func (g *Gui) closeCompletion() error {
+	g.completion = nil
+	if err := g.DeleteView(completionViewName); err != nil && !errors.Is(err, ErrUnknownView) {
+		return err
+	}
+	return nil
+}
+
+// renderCompletion displays the popup with the filtered candidates under the
+// cursor of the view, or above it if there isn't enough room below.
+func (g *Gui) renderCompletion() error {
+	c := g.completion
+	v := c.v
+
+	height := min(len(c.filtered), completionMaxHeight)
+	items := c.filtered[c.top : c.top+height]
+	displayWidth, width := 0, 0
+	for _, cand := range items {
+		displayWidth = max(displayWidth, runewidth.StringWidth(cand.display()))
+	}
+	for _, cand := range items {
+		w := displayWidth
+		if cand.Description != "" {
+			w += 2 + runewidth.StringWidth(cand.Description)
+		}
+		width = max(width, w)
+	}
+
+	x, y, _ := v.linesPosOnScreen(c.start, v.cy)
+	x0 := v.x0 + v.gutterWidth() + x - v.ox
+	row := v.y0 + 1 + y - v.oy
+	y0 := row + 1
+	if g.maxY > 0 && y0+height+1 >= g.maxY && row-height-2 >= 0 {
+		y0 = row - height - 2
+	}
+	if g.maxX > 0 && x0+width+1 >= g.maxX {
+		x0 = max(0, g.maxX-width-2)
+	}
+
+	p, err := g.SetView(completionViewName, x0, y0, x0+width+1, y0+height+1, 0)
+	if err != nil && !errors.Is(err, ErrUnknownView) {
+		return err
+	}
+	if _, err := g.SetViewOnTop(completionViewName); err != nil {
+		return err
+	}
+
+	selected := v.roleStyleOr(RoleSelection, Style{Fg: AttrReverse})
+	muted := v.roleStyleOr(RoleMuted, Style{Fg: AttrDim})
+	lines := make([][]cell, len(items))
+	for i, cand := range items {
+		display := cand.display()
+		line := styledCells(display, Style{})
+		if cand.Description != "" {
+			line = append(line, styledCells(strings.Repeat(" ", displayWidth-runewidth.StringWidth(display)+2), Style{})...)
+			line = append(line, styledCells(cand.Description, muted)...)
+		}
+		if c.top+i == c.index {
+			for lineWidth(line, defaultTabWidth) < width {
+				line = append(line, cell{chr: ' '})
+			}
+			for j := range line {
+				line[j] = styleCell(line[j], selected)
+			}
+		}
+		lines[i] = line
+	}
+	p.lines = lines
+	p.ox, p.oy = 0, 0
+	p.tainted = true
+	return nil
+}
+
+// display returns the text displayed for the candidate in the popup.
+func (c Completion) display() string {
+	if c.Display != "" {
+		return c.Display
+	}
+	return c.Text
+}
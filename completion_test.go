@@ -0,0 +1,119 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// wordCompleter completes the word before the cursor with a list of words.
+func wordCompleter(words ...string) Completer {
+	return CompleterFunc(func(buffer string, x, y int) ([]Completion, int) {
+		line := strings.Split(buffer, "\n")[y]
+		start := strings.LastIndex(line[:x], " ") + 1
+		var candidates []Completion
+		for _, w := range words {
+			candidates = append(candidates, Completion{Text: w, Description: "word"})
+		}
+		return candidates, start
+	})
+}
+
+func TestCompletion(t *testing.T) {
+	for _, editor := range []Editor{DefaultEditor, NewReadlineEditor()} {
+		g := &Gui{}
+		v, _ := g.SetView("main", 0, 0, 30, 5, 0)
+		v.Editable = true
+		v.Editor = editor
+		v.Completer = wordCompleter("foo", "foobar", "bar")
+		g.currentView = v
+
+		press := func(key Key, ch rune) {
+			if err := g.onKey(&gocuiEvent{Type: eventKey, Key: key, Ch: ch}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		popup := func() []string {
+			p, err := g.View(completionViewName)
+			if err != nil {
+				return nil
+			}
+			return p.BufferLines()
+		}
+
+		// the offsets of the completer are bytes
+		press(0, '\u00e9')
+		press(KeySpace, 0)
+		press(0, 'f')
+		press(KeyTab, 0)
+		if got := popup(); len(got) != 2 || !strings.HasPrefix(got[0], "foo ") {
+			t.Fatalf("got popup %q, want foo and foobar", got)
+		}
+
+		press(KeyTab, 0)
+		press(0, 'o')
+		press(0, 'o')
+		press(0, 'b')
+		if got := popup(); len(got) != 1 || !strings.HasPrefix(got[0], "foobar") {
+			t.Fatalf("got popup %q, want it filtered to foobar", got)
+		}
+
+		press(KeyEnter, 0)
+		if got := v.Buffer(); got != "\u00e9 foobar" {
+			t.Errorf("got %q, want %q", got, "\u00e9 foobar")
+		}
+		if popup() != nil || g.completion != nil {
+			t.Errorf("popup not closed")
+		}
+
+		// a single candidate is inserted right away
+		press(KeySpace, 0)
+		press(0, 'b')
+		press(KeyTab, 0)
+		if got := v.Buffer(); got != "\u00e9 foobar bar" || popup() != nil {
+			t.Errorf("got %q, want %q", got, "\u00e9 foobar bar")
+		}
+	}
+}
+
+func TestCompletionBindingsAndEditor(t *testing.T) {
+	g := &Gui{}
+	v, _ := g.SetView("main", 0, 0, 30, 5, 0)
+	v.Editable = true
+	v.Editor = NewVimEditor()
+	v.Completer = wordCompleter("foo", "foobar")
+	g.currentView = v
+	press := func(key Key, ch rune) {
+		if err := g.onKey(&gocuiEvent{Type: eventKey, Key: key, Ch: ch}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the keybindings of the application come first
+	tabs := 0
+	if err := g.SetKeybinding("main", KeyTab, ModNone, func(*Gui, *View) error { tabs++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	press(KeyTab, 0)
+	if tabs != 1 || g.completion != nil {
+		t.Fatalf("got %d Tab bindings run and popup %v, want the binding run", tabs, g.completion != nil)
+	}
+	g.DeleteKeybindings("main")
+
+	// the candidate is inserted in the normal mode of the vim editor
+	fmt.Fprint(v, "x fo")
+	v.SetCursor(4, 0)
+	press(KeyTab, 0)
+	press(KeyTab, 0)
+	press(KeyEnter, 0)
+	if got := v.Buffer(); got != "x foobar" {
+		t.Errorf("got %q, want %q", got, "x foobar")
+	}
+	if !v.Undo() || v.Buffer() != "x fo" {
+		t.Errorf("got %q after undo, want %q", v.Buffer(), "x fo")
+	}
+}
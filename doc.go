@@ -68,6 +68,21 @@ Mouse events are handled like any other keybinding:
 		// handle error
 	}
 
+Moving the mouse while a button is held down generates MouseDrag events, which
+are sent to the view where the drag started (see *Gui.MouseDragStart). Double
+clicks trigger MouseLeftDouble, falling back to MouseLeft if it is not bound,
+and *Gui.MouseClickCount reports longer click sequences. Set g.MouseMotion to
+also receive MouseMove events when no button is pressed.
+
+As mouse mode disables the selection of the terminal, views can handle it
+themselves: when v.Selectable is set, text is selected by dragging, double
+clicking a word or triple clicking a line. The selection is copied to the
+system clipboard with OSC 52:
+
+	g.SetKeybinding("viewname", gocui.KeyCtrlC, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return v.CopySelection()
+	})
+
 IMPORTANT: Views can only be created, destroyed or updated in three ways: from
 the Layout function within managers, from keybinding callbacks or via
 *Gui.Update(). The reason for this is that it allows gocui to be
@@ -84,8 +99,30 @@ use *Gui.Update(). For example:
 		return nil
 	})
 
-By default, gocui provides a basic editing mode. This mode can be extended
-and customized creating a new Editor and assigning it to *View.Editor:
+By default, gocui provides a basic editing mode, with undo (Ctrl+Z) and redo
+(Ctrl+Y). ReadlineEditor provides the shell-like key bindings of GNU Readline,
+where Ctrl+Y yanks and Alt+_ redoes:
+
+	v.Editor = gocui.NewReadlineEditor()
+
+VimEditor provides the modal editing of vim. Its mode can be shown in a
+status line:
+
+	e := gocui.NewVimEditor()
+	e.OnModeChange = func(v *gocui.View, mode gocui.VimMode) {
+		// display mode.String()
+	}
+	v.Editor = e
+
+An editable view with a Completer shows completions in a popup when Tab is
+pressed, whatever its Editor, unless Tab matches a keybinding:
+
+	v.Completer = gocui.CompleterFunc(func(buffer string, x, y int) ([]gocui.Completion, int) {
+		return candidates, wordStart
+	})
+
+This mode can be extended and customized creating a new Editor and assigning
+it to *View.Editor:
 
 	type Editor interface {
 		Edit(v *View, key Key, ch rune, mod Modifier)
@@ -113,6 +150,51 @@ Views allow to add colored text using ANSI colors. For example:
 
 	fmt.Fprintln(v, "\x1b[0;31mHello world")
 
+Text can also be written with a given style, without escape sequences:
+
+	v.Print(gocui.Style{Fg: gocui.ColorRed | gocui.AttrBold}, "ERROR ")
+	v.WriteStyled("Hello world\n", gocui.ColorGreen, gocui.ColorDefault)
+
+Themes:
+
+Instead of setting the colors of the GUI and of each view, a Theme associates
+styles to roles, like the frame, the title or the selected line. It can be
+loaded from a JSON file and switched at runtime:
+
+	theme, err := gocui.LoadTheme("theme.json")
+	g.SetTheme(theme)
+	v.SetRoleStyle(gocui.RoleFrame, gocui.Style{Fg: gocui.ColorRed})
+	v.Print(v.RoleStyle(gocui.RoleError), "failed\n")
+
+Code and log viewers can show line numbers and signs in a gutter at the left
+of views:
+
+	v.LineNumbers = gocui.LineNumbersAbsolute
+	v.SignColumn = true
+	v.SetSign(41, gocui.Sign{Text: "●", Style: gocui.Style{Fg: gocui.ColorRed}})
+
+A Styler styles the visible lines of a view when it is drawn, without
+rewriting its buffer, e.g. to highlight the syntax of an editable view:
+
+	v.Styler = gocui.GoHighlighter
+
+Terminal views:
+
+A TerminalView runs a command in a pseudo-terminal and shows its output in a
+view, forwarding the keys pressed in the view to the command:
+
+	tv := gocui.NewTerminalView("shell", exec.Command("bash"))
+	v, err := tv.SetView(g, 0, 0, maxX-1, maxY-1)
+
+Input fields:
+
+An Input is a single-line prompt with a placeholder, validation and a
+history browsed with Up and Down:
+
+	in := gocui.NewInput("prompt")
+	in.OnSubmit = func(g *gocui.Gui, text string) error { ... }
+	v, err := in.SetView(g, 0, maxY-3, maxX-1, maxY-1)
+
 For more information, see the examples in folder "_examples/".
 */
 package gocui
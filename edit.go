@@ -6,6 +6,7 @@ package gocui
 
 import (
 	"errors"
+	"strings"
 )
 
 // Editor interface must be satisfied by gocui editors.
@@ -54,6 +55,10 @@ func simpleEditor(v *View, key Key, ch rune, mod Modifier) {
 		v.MoveCursor(1, 0)
 	case KeyTab:
 		v.EditWrite('\t')
+	case KeyCtrlZ:
+		v.Undo()
+	case KeyCtrlY:
+		v.Redo()
 	case KeyEsc:
 		// If not here the esc key will act like the KeySpace
 	default:
@@ -63,6 +68,17 @@ func simpleEditor(v *View, key Key, ch rune, mod Modifier) {
 
 // EditWrite writes a rune at the cursor position.
 func (v *View) EditWrite(ch rune) {
+	v.edit(editInsert, v.cy, v.cy, func() {
+		v.editWrite(ch)
+	})
+}
+
+func (v *View) editWrite(ch rune) {
+	// combining characters are added to the grapheme cluster before the
+	// cursor
+	if v.joinCluster(v.cx, v.cy, ch) {
+		return
+	}
 	v.writeRune(v.cx, v.cy, ch)
 	v.MoveCursor(1, 0)
 }
@@ -112,6 +128,17 @@ func (v *View) EditGotoToEndOfLine() {
 // EditDelete deletes a rune at the cursor position. back determines the
 // direction.
 func (v *View) EditDelete(back bool) {
+	kind := editDeleteForward
+	if back {
+		kind = editDeleteBack
+	}
+	// the line of the cursor may be merged with the previous or next one
+	v.edit(kind, v.cy-1, v.cy+1, func() {
+		v.editDelete(back)
+	})
+}
+
+func (v *View) editDelete(back bool) {
 	x, y := v.cx, v.cy
 	if y < 0 {
 		return
@@ -147,12 +174,125 @@ func (v *View) EditDelete(back bool) {
 
 // EditNewLine inserts a new line under the cursor.
 func (v *View) EditNewLine() {
+	v.edit(editOther, v.cy, v.cy, v.editNewLine)
+}
+
+func (v *View) editNewLine() {
 	v.breakLine(v.cx, v.cy)
 	v.ox = 0
 	v.cy = v.cy + 1
 	v.cx = 0
 }
 
+// charAt returns the rune at a position of the buffer, '\n' at the end of
+// the lines but the last one, and 0 at the end of the buffer.
+func (v *View) charAt(p bufferPos) rune {
+	if p.y < 0 || p.y >= len(v.lines) {
+		return 0
+	}
+	if p.x < len(v.lines[p.y]) {
+		return v.lines[p.y][p.x].chr
+	}
+	if p.y+1 < len(v.lines) {
+		return '\n'
+	}
+	return 0
+}
+
+// nextPos returns the position following p in the buffer, going to the next
+// line after the end of a line. It returns false at the end of the buffer.
+func (v *View) nextPos(p bufferPos) (bufferPos, bool) {
+	if p.y >= len(v.lines) {
+		return p, false
+	}
+	if p.x < len(v.lines[p.y]) {
+		return bufferPos{p.x + 1, p.y}, true
+	}
+	if p.y+1 < len(v.lines) {
+		return bufferPos{0, p.y + 1}, true
+	}
+	return p, false
+}
+
+// prevPos returns the position preceding p in the buffer, going to the end
+// of the previous line from the start of a line. It returns false at the
+// start of the buffer.
+func (v *View) prevPos(p bufferPos) (bufferPos, bool) {
+	if p.x > 0 {
+		return bufferPos{p.x - 1, p.y}, true
+	}
+	if p.y > 0 && p.y-1 < len(v.lines) {
+		return bufferPos{len(v.lines[p.y-1]), p.y - 1}, true
+	}
+	return p, false
+}
+
+// cursorPos returns the position of the cursor in the buffer.
+func (v *View) cursorPos() bufferPos {
+	return bufferPos{v.cx, v.cy}
+}
+
+// moveCursorTo moves the cursor to a position of the buffer, scrolling the
+// view if needed.
+func (v *View) moveCursorTo(p bufferPos) {
+	v.MoveCursor(p.x-v.cx, p.y-v.cy)
+}
+
+// textRange returns the text of the buffer from start to end (excluded).
+func (v *View) textRange(start, end bufferPos) string {
+	var b strings.Builder
+	for p := start; p.before(end); {
+		ch := v.charAt(p)
+		if ch == 0 {
+			break
+		}
+		b.WriteRune(ch)
+		if p.x < len(v.lines[p.y]) {
+			for _, r := range v.lines[p.y][p.x].comb {
+				b.WriteRune(r)
+			}
+		}
+		p, _ = v.nextPos(p)
+	}
+	return b.String()
+}
+
+// deleteRange removes the text of the buffer from start to end (excluded)
+// and moves the cursor to start.
+func (v *View) deleteRange(start, end bufferPos) {
+	v.tainted = true
+	if start.y >= len(v.lines) || !start.before(end) {
+		return
+	}
+	if end.y >= len(v.lines) {
+		end = bufferPos{len(v.lines[len(v.lines)-1]), len(v.lines) - 1}
+	}
+	first, last := v.lines[start.y], v.lines[end.y]
+	start.x = min(start.x, len(first))
+	end.x = min(end.x, len(last))
+
+	line := append(append([]cell(nil), first[:start.x]...), last[end.x:]...)
+	v.lines[start.y] = line
+	v.lines = append(v.lines[:start.y+1], v.lines[end.y+1:]...)
+	v.shiftSigns(start.y+1, start.y-end.y)
+	v.moveCursorTo(start)
+}
+
+// insertText inserts text at the cursor position and moves the cursor after
+// it.
+func (v *View) insertText(text string) {
+	for _, ch := range text {
+		if ch == '\n' {
+			for v.cy >= len(v.lines) {
+				v.lines = append(v.lines, nil)
+			}
+			v.editNewLine()
+			continue
+		}
+		v.editWrite(ch)
+	}
+}
+
 // MoveCursor mores the cursor relative from it's current possition
 func (v *View) MoveCursor(dx, dy int) {
 	newX, newY := v.cx+dx, v.cy+dy
@@ -207,8 +347,13 @@ func (v *View) MoveCursor(dx, dy int) {
 	}
 
 	if !v.Wrap {
-		if newXOnScreen > v.ox+maxX-1 {
-			v.ox = newXOnScreen - maxX + 1
+		// the whole character under the cursor must be visible
+		w := 1
+		if newX < len(v.lines[newY]) {
+			w = max(cellWidthAt(v.lines[newY][newX], newXOnScreen, v.tabWidth()), 1)
+		}
+		if newXOnScreen+w-1 > v.ox+maxX-1 {
+			v.ox = newXOnScreen + w - maxX
 		}
 		if newXOnScreen < v.ox {
 			v.ox = newXOnScreen
@@ -234,26 +379,27 @@ func (v *View) writeRune(x, y int, ch rune) error {
 		v.lines = append(v.lines, newLines...)
 	}
 
+	// the line is replaced instead of modified in place, see edit
 	line := v.lines[y]
-	lineLen := len(line)
-
-	var toInsert []cell
-	if x >= lineLen {
-		toInsert = make([]cell, x-lineLen+1)
-	} else if !v.Overwrite {
-		toInsert = make([]cell, 1)
-	}
-	v.lines[y] = append(v.lines[y], toInsert...)
-
-	if !v.Overwrite || (v.Overwrite && x+1 >= lineLen) {
-		copy(v.lines[y][x+1:], v.lines[y][x:])
+	var newLine []cell
+	switch {
+	case x >= len(line):
+		newLine = make([]cell, x+1)
+		copy(newLine, line)
+	case v.Overwrite:
+		newLine = append([]cell(nil), line...)
+	default:
+		newLine = make([]cell, len(line)+1)
+		copy(newLine, line[:x])
+		copy(newLine[x+1:], line[x:])
 	}
 
-	v.lines[y][x] = cell{
+	newLine[x] = cell{
 		fgColor: v.FgColor,
 		bgColor: v.BgColor,
 		chr:     ch,
 	}
+	v.lines[y] = newLine
 
 	return nil
 }
@@ -268,7 +414,7 @@ func (v *View) deleteRune(x, y int) error {
 		return errors.New("invalid point")
 	}
 
-	v.lines[y] = append(v.lines[y][:x], v.lines[y][x+1:]...)
+	v.lines[y] = append(append([]cell(nil), v.lines[y][:x]...), v.lines[y][x+1:]...)
 	return nil
 }
 
@@ -281,8 +427,9 @@ func (v *View) mergeLines(y int) error {
 	}
 
 	if y+1 < len(v.lines) { // If we are already on the last line this would panic
-		v.lines[y] = append(v.lines[y], v.lines[y+1]...)
+		v.lines[y] = append(append([]cell(nil), v.lines[y]...), v.lines[y+1]...)
 		v.lines = append(v.lines[:y+1], v.lines[y+2:]...)
+		v.shiftSigns(y+1, -1)
 	}
 	return nil
 }
@@ -312,5 +459,11 @@ func (v *View) breakLine(x, y int) error {
 	copy(lines, v.lines[:y])
 	copy(lines[y+2:], v.lines[y+1:])
 	v.lines = lines
+	// the sign follows the text of the line when it is broken at its start
+	if x == 0 {
+		v.shiftSigns(y, 1)
+	} else {
+		v.shiftSigns(y+1, 1)
+	}
 	return nil
 }
@@ -0,0 +1,119 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "testing"
+
+// editorKey is a key press sent to an editor by the tests.
+type editorKey struct {
+	key Key
+	ch  rune
+	mod Modifier
+}
+
+// typeKeys sends the runes of text, then the keys, to an editor.
+func typeKeys(e Editor, v *View, text string, keys ...editorKey) {
+	for _, ch := range text {
+		e.Edit(v, 0, ch, ModNone)
+	}
+	for _, k := range keys {
+		e.Edit(v, k.key, k.ch, k.mod)
+	}
+}
+
+func TestReadlineEditor(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		keys   []editorKey
+		want   string
+		wantCx int
+	}{
+		{"start and end", "abc", []editorKey{{key: KeyCtrlA}, {ch: 'x'}, {key: KeyCtrlE}, {ch: 'y'}}, "xabcy", 5},
+		{"words", "foo bar baz", []editorKey{{ch: 'b', mod: ModAlt}, {ch: 'b', mod: ModAlt}, {ch: 'f', mod: ModAlt}}, "foo bar baz", 7},
+		{"kill word backward", "foo bar-baz", []editorKey{{key: KeyCtrlW}}, "foo ", 4},
+		{"kill word", "foo bar-baz", []editorKey{{key: KeyBackspace2, mod: ModAlt}}, "foo bar-", 8},
+		{"kill forward word", "foo bar", []editorKey{{key: KeyCtrlA}, {ch: 'd', mod: ModAlt}}, " bar", 0},
+		{"kill line and yank", "foo bar", []editorKey{{key: KeyCtrlA}, {key: KeyCtrlK}, {key: KeyCtrlY}, {key: KeyCtrlY}}, "foo barfoo bar", 14},
+		{"consecutive kills", "one two", []editorKey{{key: KeyCtrlW}, {key: KeyCtrlW}, {key: KeyCtrlY}}, "one two", 7},
+		{"yank pop", "one two", []editorKey{{key: KeyCtrlW}, {ch: 'x'}, {key: KeyCtrlW}, {key: KeyCtrlY}, {ch: 'y', mod: ModAlt}}, "one two", 7},
+		{"transpose", "ab", []editorKey{{key: KeyCtrlT}}, "ba", 2},
+		{"undo", "abc", []editorKey{{key: KeyCtrlW}, {key: KeyCtrlUnderscore}}, "abc", 3},
+		{"redo", "abc", []editorKey{{key: KeyCtrlW}, {key: KeyCtrlUnderscore}, {ch: '_', mod: ModAlt}}, "", 0},
+	}
+
+	for _, test := range tests {
+		v := newTestView(40, 3)
+		v.Editable = true
+		e := NewReadlineEditor()
+		typeKeys(e, v, test.text, test.keys...)
+		if got := v.Buffer(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+		if x, _ := v.Cursor(); x != test.wantCx {
+			t.Errorf("%s: got cursor at %d, want %d", test.name, x, test.wantCx)
+		}
+	}
+}
+
+func TestVimEditor(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     string
+		want     string
+		wantX    int
+		wantY    int
+		wantMode VimMode
+	}{
+		{"insert", "ifoo\x1b", "foo", 2, 0, VimNormal},
+		{"append", "ifoo\x1bab\x1b", "foob", 3, 0, VimNormal},
+		{"motions", "ione two three\x1b0wwbe", "one two three", 6, 0, VimNormal},
+		{"count", "ione two three\x1b02w", "one two three", 8, 0, VimNormal},
+		{"delete word", "ione two three\x1b0dw", "two three", 0, 0, VimNormal},
+		{"delete with count", "ione two three\x1b0d2w", "three", 0, 0, VimNormal},
+		{"change word", "ione two\x1b0cwsix\x1b", "six two", 2, 0, VimNormal},
+		{"change inner word", "ione two\x1bciwsix\x1b", "one six", 6, 0, VimNormal},
+		{"delete around word", "ione two three\x1b0wdaw", "one three", 4, 0, VimNormal},
+		{"delete to end", "ione two\x1b0wD", "one ", 3, 0, VimNormal},
+		{"delete line", "ione\ntwo\nthree\x1bggdd", "two\nthree", 0, 0, VimNormal},
+		{"delete last line", "ione\ntwo\x1bdd", "one", 0, 0, VimNormal},
+		{"yank and put line", "ione\ntwo\x1bggyyjp", "one\ntwo\none", 0, 2, VimNormal},
+		{"delete and put", "iabc\x1b0xp", "bac", 1, 0, VimNormal},
+		{"change line", "ione\ntwo\x1bccsix\x1b", "one\nsix", 2, 1, VimNormal},
+		{"go to line", "ione\ntwo\nthree\x1bgg2G", "one\ntwo\nthree", 0, 1, VimNormal},
+		{"open line", "ione\x1bOtwo\x1b", "two\none", 2, 0, VimNormal},
+		{"repeat", "ione two three four\x1b0dw..", "four", 0, 0, VimNormal},
+		{"repeat insert", "ione\x1bA!\x1bj.", "one!!", 4, 0, VimNormal},
+		{"visual", "ione two\x1b0vld", "e two", 0, 0, VimNormal},
+		{"visual line", "ione\ntwo\nthree\x1bggVjd", "three", 0, 0, VimNormal},
+		{"undo", "ione two\x1b0dwu", "one two", 0, 0, VimNormal},
+		{"modes", "ione\x1bv", "one", 2, 0, VimVisual},
+	}
+
+	for _, test := range tests {
+		v := newTestView(40, 5)
+		v.Editable = true
+		e := NewVimEditor()
+		for _, ch := range test.keys {
+			switch ch {
+			case '\x1b':
+				e.Edit(v, KeyEsc, 0, ModNone)
+			case '\n':
+				e.Edit(v, KeyEnter, 0, ModNone)
+			default:
+				e.Edit(v, 0, ch, ModNone)
+			}
+		}
+		if got := v.Buffer(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+		if x, y := v.Cursor(); x != test.wantX || y != test.wantY {
+			t.Errorf("%s: got cursor at %d,%d, want %d,%d", test.name, x, y, test.wantX, test.wantY)
+		}
+		if e.Mode() != test.wantMode {
+			t.Errorf("%s: got mode %v, want %v", test.name, e.Mode(), test.wantMode)
+		}
+	}
+}
@@ -7,16 +7,48 @@ package gocui
 import (
 	"errors"
 	"strconv"
+	"strings"
 )
 
 type escapeInterpreter struct {
 	state                  escapeState
 	curch                  rune
 	csiParam               []string
+	csiPrivate             rune
+	raw                    []rune
+	stringLen              int
+	instruction            instruction
 	curFgColor, curBgColor Attribute
 	mode                   OutputMode
 }
 
+// instruction is a control sequence other than SGR. Cursor control sequences
+// are executed by the view when View.ControlSequences is true, the other ones
+// are only used by TerminalView.
+type instruction struct {
+	// kind is the final rune of the sequence, e.g. 'K' for "erase in
+	// line". It is 0 if there is no instruction.
+	kind rune
+
+	// private is the private parameter marker of a CSI sequence, e.g. '?',
+	// or the designated set ('(', ')', ...) of a character set sequence.
+	private rune
+
+	// esc is true for sequences which are not CSI sequences, e.g. ESC 7.
+	esc bool
+
+	params []int
+}
+
+// param returns the i-th parameter of the instruction, or def if it is
+// missing or 0.
+func (ins instruction) param(i, def int) int {
+	if i >= len(ins.params) || ins.params[i] == 0 {
+		return def
+	}
+	return ins.params[i]
+}
+
 type (
 	escapeState int
 	fontEffect  int
@@ -27,6 +59,10 @@ const (
 	stateEscape
 	stateCSI
 	stateParams
+	stateIntermediate
+	stateCharset
+	stateOSC
+	stateOSCEscape
 
 	bold               fontEffect = 1
 	faint              fontEffect = 2
@@ -35,10 +71,23 @@ const (
 	blink              fontEffect = 5
 	reverse            fontEffect = 7
 	strike             fontEffect = 9
+	doubleUnderline    fontEffect = 21
+	normalIntensity    fontEffect = 22
+	notItalic          fontEffect = 23
+	notUnderline       fontEffect = 24
+	notBlink           fontEffect = 25
+	notReverse         fontEffect = 27
+	notStrike          fontEffect = 29
 	setForegroundColor fontEffect = 38
+	defaultForeground  fontEffect = 39
 	setBackgroundColor fontEffect = 48
+	defaultBackground  fontEffect = 49
 )
 
+// maxStringLen is the maximum number of runes of OSC and DCS strings. An
+// unterminated string doesn't hide the output following it.
+const maxStringLen = 4096
+
 var (
 	errNotCSI        = errors.New("not a CSI escape sequence")
 	errCSIParseError = errors.New("CSI escape sequence parsing error")
@@ -47,22 +96,10 @@ var (
 
 // runes in case of error will output the non-parsed runes as a string.
 func (ei *escapeInterpreter) runes() []rune {
-	switch ei.state {
-	case stateNone:
+	if len(ei.raw) == 0 {
 		return []rune{0x1b}
-	case stateEscape:
-		return []rune{0x1b, ei.curch}
-	case stateCSI:
-		return []rune{0x1b, '[', ei.curch}
-	case stateParams:
-		ret := []rune{0x1b, '['}
-		for _, s := range ei.csiParam {
-			ret = append(ret, []rune(s)...)
-			ret = append(ret, ';')
-		}
-		return append(ret, ei.curch)
 	}
-	return nil
+	return ei.raw
 }
 
 // newEscapeInterpreter returns an escapeInterpreter that will be able to parse
@@ -82,12 +119,25 @@ func (ei *escapeInterpreter) reset() {
 	ei.state = stateNone
 	ei.curFgColor = ColorDefault
 	ei.curBgColor = ColorDefault
+	ei.endSequence()
+}
+
+// endSequence gets ready to parse the next escape sequence, keeping the
+// current colors.
+func (ei *escapeInterpreter) endSequence() {
+	ei.state = stateNone
 	ei.csiParam = nil
+	ei.csiPrivate = 0
+	ei.raw = ei.raw[:0]
+	ei.stringLen = 0
 }
 
 // parseOne parses a rune. If isEscape is true, it means that the rune is part
 // of an escape sequence, and as such should not be printed verbatim. Otherwise,
 // it's not an escape sequence.
+//
+// Sequences which are well formed but not supported, like OSC and DCS strings
+// or CSI sequences other than SGR, are consumed without effect.
 func (ei *escapeInterpreter) parseOne(ch rune) (isEscape bool, err error) {
 	// Sanity checks
 	if len(ei.csiParam) > 20 {
@@ -98,26 +148,68 @@ func (ei *escapeInterpreter) parseOne(ch rune) (isEscape bool, err error) {
 	}
 
 	ei.curch = ch
+	if ei.state != stateNone && ei.state != stateOSC {
+		ei.raw = append(ei.raw, ch)
+	}
 
 	switch ei.state {
 	case stateNone:
 		if ch == 0x1b {
 			ei.state = stateEscape
+			ei.raw = append(ei.raw[:0], ch)
 			return true, nil
 		}
 		return false, nil
 	case stateEscape:
-		if ch == '[' {
+		switch {
+		case ch == '[':
 			ei.state = stateCSI
-			return true, nil
+		case ch == ']' || ch == 'P' || ch == 'X' || ch == '^' || ch == '_':
+			// OSC, DCS, SOS, PM and APC strings
+			ei.state = stateOSC
+		case ch == '(' || ch == ')' || ch == '*' || ch == '+':
+			// character set designation, followed by one more rune
+			ei.state = stateCharset
+		case ch >= 0x30 && ch <= 0x7e:
+			// single character sequences, e.g. ESC 7 or ESC =
+			ei.endSequence()
+			ei.instruction = instruction{kind: ch, esc: true}
+		default:
+			return false, errNotCSI
+		}
+		return true, nil
+	case stateCharset:
+		designated := ei.raw[1]
+		ei.endSequence()
+		ei.instruction = instruction{kind: ch, private: designated, esc: true}
+		return true, nil
+	case stateOSC:
+		// the strings are terminated by BEL or ST (ESC \)
+		if ei.stringLen++; ei.stringLen > maxStringLen {
+			ei.endSequence()
+			return false, nil
+		}
+		switch ch {
+		case 0x07:
+			ei.endSequence()
+		case 0x1b:
+			ei.state = stateOSCEscape
 		}
-		return false, errNotCSI
+		return true, nil
+	case stateOSCEscape:
+		ei.endSequence()
+		return true, nil
 	case stateCSI:
+		if ch >= '<' && ch <= '?' {
+			// private parameter marker, e.g. ESC [ ? 25 l
+			ei.csiPrivate = ch
+			ei.state = stateParams
+			return true, nil
+		}
 		switch {
-		case ch >= '0' && ch <= '9':
+		case ch >= '0' && ch <= '9', ch == ';', ch == ':':
 			ei.csiParam = append(ei.csiParam, "")
-		case ch == 'm':
-			ei.csiParam = append(ei.csiParam, "0")
+		case ch >= 0x20 && ch <= 0x2f, ch >= 0x40 && ch <= 0x7e:
 		default:
 			return false, errCSIParseError
 		}
@@ -125,28 +217,33 @@ func (ei *escapeInterpreter) parseOne(ch rune) (isEscape bool, err error) {
 		fallthrough
 	case stateParams:
 		switch {
-		case ch >= '0' && ch <= '9':
+		case ch >= '0' && ch <= '9', ch == ':':
+			if len(ei.csiParam) == 0 {
+				ei.csiParam = append(ei.csiParam, "")
+			}
 			ei.csiParam[len(ei.csiParam)-1] += string(ch)
 			return true, nil
 		case ch == ';':
+			if len(ei.csiParam) == 0 {
+				ei.csiParam = append(ei.csiParam, "")
+			}
 			ei.csiParam = append(ei.csiParam, "")
 			return true, nil
-		case ch == 'm':
-			var err error
-			switch ei.mode {
-			case OutputNormal:
-				err = ei.outputNormal()
-			case Output256:
-				err = ei.output256()
-			case OutputTrue:
-				err = ei.outputTrue()
-			}
-			if err != nil {
-				return false, errCSIParseError
-			}
-
-			ei.state = stateNone
-			ei.csiParam = nil
+		case ch >= 0x20 && ch <= 0x2f:
+			// intermediate bytes, no sequence using them is supported
+			ei.state = stateIntermediate
+			return true, nil
+		case ch >= 0x40 && ch <= 0x7e:
+			return ei.finishCSI(ch)
+		default:
+			return false, errCSIParseError
+		}
+	case stateIntermediate:
+		switch {
+		case ch >= 0x20 && ch <= 0x2f:
+			return true, nil
+		case ch >= 0x40 && ch <= 0x7e:
+			ei.endSequence()
 			return true, nil
 		default:
 			return false, errCSIParseError
@@ -155,159 +252,223 @@ func (ei *escapeInterpreter) parseOne(ch rune) (isEscape bool, err error) {
 	return false, nil
 }
 
-// outputNormal provides 8 different colors:
-//   black, red, green, yellow, blue, magenta, cyan, white
-func (ei *escapeInterpreter) outputNormal() error {
-	for _, param := range ei.csiParam {
-		p, err := strconv.Atoi(param)
-		if err != nil {
-			return errCSIParseError
-		}
+// takeInstruction returns the cursor control sequence parsed last, if any,
+// and clears it.
+func (ei *escapeInterpreter) takeInstruction() instruction {
+	ins := ei.instruction
+	ei.instruction = instruction{}
+	return ins
+}
 
-		switch {
-		case p >= 30 && p <= 37:
-			ei.curFgColor = Get256Color(int32(p) - 30)
-		case p == 39:
-			ei.curFgColor = ColorDefault
-		case p >= 40 && p <= 47:
-			ei.curBgColor = Get256Color(int32(p) - 40)
-		case p == 49:
-			ei.curBgColor = ColorDefault
-		case p == 0:
-			ei.curFgColor = ColorDefault
-			ei.curBgColor = ColorDefault
-		default:
-			ei.curFgColor |= getFontEffect(p)
+// finishCSI executes a complete CSI sequence ending with the final rune ch.
+// SGR sequences (ending with 'm') change the current colors, other sequences
+// are kept as instruction for the view.
+func (ei *escapeInterpreter) finishCSI(ch rune) (bool, error) {
+	if ch == 'm' && ei.csiPrivate == 0 {
+		if err := ei.outputSGR(); err != nil {
+			return false, errCSIParseError
 		}
+		ei.endSequence()
+		return true, nil
 	}
 
-	return nil
+	ins := instruction{kind: ch, private: ei.csiPrivate}
+	for _, param := range ei.csiParam {
+		p, err := atoiDefault(param)
+		if err != nil {
+			// e.g. sub-parameters, which no instruction supports
+			ei.endSequence()
+			return true, nil
+		}
+		ins.params = append(ins.params, p)
+	}
+	ei.endSequence()
+	ei.instruction = ins
+	return true, nil
 }
 
-// output256 allows you to leverage the 256-colors terminal mode:
-//   0x01 - 0x08: the 8 colors as in OutputNormal
-//   0x09 - 0x10: Color* | AttrBold
-//   0x11 - 0xe8: 216 different colors
-//   0xe9 - 0x1ff: 24 different shades of grey
-func (ei *escapeInterpreter) output256() error {
-	if len(ei.csiParam) < 3 {
-		return ei.outputNormal()
+// outputSGR applies the parameters of a "Select Graphic Rendition" sequence
+// to the current colors and attributes. The supported parameters are:
+//   0:              reset all
+//   1-9, 21-29:     set and reset text attributes
+//   30-37, 90-97:   foreground colors, normal and bright
+//   40-47, 100-107: background colors, normal and bright
+//   38, 48:         extended foreground and background colors, in the forms
+//                   `5;<n>`, `2;<r>;<g>;<b>`, `5:<n>` and `2:[<cs>]:<r>:<g>:<b>`
+//   39, 49:         default foreground and background colors
+// Unknown parameters and malformed or out of range colors are ignored.
+func (ei *escapeInterpreter) outputSGR() error {
+	params := ei.csiParam
+	if len(params) == 0 {
+		params = []string{"0"}
 	}
 
-	mode, err := strconv.Atoi(ei.csiParam[1])
-	if err != nil {
-		return errCSIParseError
-	}
-	if mode != 5 {
-		return ei.outputNormal()
-	}
+	for i := 0; i < len(params); i++ {
+		param := params[i]
 
-	for _, param := range splitFgBg(ei.csiParam, 3) {
-		fgbg, err := strconv.Atoi(param[0])
-		if err != nil {
-			return errCSIParseError
+		if strings.Contains(param, ":") {
+			sub := strings.Split(param, ":")
+			p, err := atoiDefault(sub[0])
+			if err != nil {
+				return err
+			}
+			if fontEffect(p) != setForegroundColor && fontEffect(p) != setBackgroundColor {
+				// e.g. curly underline `4:3`
+				ei.setFontEffect(p)
+				continue
+			}
+			color, _, err := ei.extendedColor(sub[1:], true)
+			if err != nil {
+				// a malformed or out of range color is dropped
+				continue
+			}
+			ei.setColor(fontEffect(p), color)
+			continue
 		}
-		color, err := strconv.Atoi(param[2])
+
+		p, err := atoiDefault(param)
 		if err != nil {
-			return errCSIParseError
+			return err
 		}
 
-		switch fontEffect(fgbg) {
-		case setForegroundColor:
-			ei.curFgColor = Get256Color(int32(color))
-
-			for _, s := range param[3:] {
-				p, err := strconv.Atoi(s)
-				if err != nil {
-					return errCSIParseError
-				}
-
-				ei.curFgColor |= getFontEffect(p)
+		switch {
+		case p == 0:
+			ei.curFgColor = ColorDefault
+			ei.curBgColor = ColorDefault
+		case p >= 30 && p <= 37:
+			ei.setColor(setForegroundColor, ei.paletteColor(p-30))
+		case p >= 40 && p <= 47:
+			ei.setColor(setBackgroundColor, ei.paletteColor(p-40))
+		case p >= 90 && p <= 97:
+			ei.setColor(setForegroundColor, ei.paletteColor(p-90+8))
+		case p >= 100 && p <= 107:
+			ei.setColor(setBackgroundColor, ei.paletteColor(p-100+8))
+		case fontEffect(p) == setForegroundColor || fontEffect(p) == setBackgroundColor:
+			color, n, err := ei.extendedColor(params[i+1:], false)
+			if err != nil {
+				// a malformed or out of range color is dropped, with
+				// the rest of the sequence since its arguments can't
+				// be told apart from the next parameters
+				return nil
 			}
-		case setBackgroundColor:
-			ei.curBgColor = Get256Color(int32(color))
+			i += n
+			ei.setColor(fontEffect(p), color)
+		case fontEffect(p) == defaultForeground:
+			ei.setColor(setForegroundColor, ColorDefault)
+		case fontEffect(p) == defaultBackground:
+			ei.setColor(setBackgroundColor, ColorDefault)
 		default:
-			return errCSIParseError
+			ei.setFontEffect(p)
 		}
 	}
 	return nil
 }
 
-// outputTrue allows you to leverage the true-color terminal mode.
-//
-// Works with rgb ANSI sequence: `\x1b[38;2;<r>;<g>;<b>m`, `\x1b[48;2;<r>;<g>;<b>m`
-func (ei *escapeInterpreter) outputTrue() error {
-	if len(ei.csiParam) < 5 {
-		return ei.output256()
+// extendedColor parses the arguments of the SGR parameters 38 and 48. It
+// returns the color and the number of arguments consumed. With colons, the
+// RGB form may have a color space identifier before the components.
+func (ei *escapeInterpreter) extendedColor(args []string, colon bool) (Attribute, int, error) {
+	if len(args) == 0 {
+		return 0, 0, errCSIParseError
 	}
-
-	mode, err := strconv.Atoi(ei.csiParam[1])
+	kind, err := atoiDefault(args[0])
 	if err != nil {
-		return errCSIParseError
-	}
-	if mode != 2 {
-		return ei.output256()
+		return 0, 0, err
 	}
 
-	for _, param := range splitFgBg(ei.csiParam, 5) {
-		fgbg, err := strconv.Atoi(param[0])
-		if err != nil {
-			return errCSIParseError
+	switch kind {
+	case 5:
+		if len(args) < 2 {
+			return 0, 0, errCSIParseError
 		}
-		colr, err := strconv.Atoi(param[2])
-		if err != nil {
-			return errCSIParseError
+		n, err := atoiDefault(args[1])
+		if err != nil || n > 255 {
+			return 0, 0, errCSIParseError
 		}
-		colg, err := strconv.Atoi(param[3])
-		if err != nil {
-			return errCSIParseError
+		return ei.paletteColor(n), 2, nil
+	case 2:
+		rgb := args[1:]
+		if colon && len(rgb) >= 4 {
+			rgb = rgb[1:] // skip the color space identifier
 		}
-		colb, err := strconv.Atoi(param[4])
-		if err != nil {
-			return errCSIParseError
+		if len(rgb) < 3 {
+			return 0, 0, errCSIParseError
 		}
-		color := NewRGBColor(int32(colr), int32(colg), int32(colb))
-
-		switch fontEffect(fgbg) {
-		case setForegroundColor:
-			ei.curFgColor = color
-
-			for _, s := range param[5:] {
-				p, err := strconv.Atoi(s)
-				if err != nil {
-					return errCSIParseError
-				}
-
-				ei.curFgColor |= getFontEffect(p)
+		var c [3]int
+		for j := range c {
+			if c[j], err = atoiDefault(rgb[j]); err != nil || c[j] > 255 {
+				return 0, 0, errCSIParseError
 			}
-		case setBackgroundColor:
-			ei.curBgColor = color
-		default:
-			return errCSIParseError
 		}
+		return ei.rgbColor(int32(c[0]), int32(c[1]), int32(c[2])), 4, nil
 	}
-	return nil
+	return 0, 0, errCSIParseError
 }
 
-// splitFgBg splits foreground and background color according to ANSI sequence.
-//
-// num (number of segments in ansi) is used to determine if it's 256 mode or rgb mode (3 - 256-color, 5 - rgb-color)
-func splitFgBg(params []string, num int) [][]string {
-	var out [][]string
-	var current []string
-	for _, p := range params {
-		if len(current) == num && (p == "48" || p == "38") {
-			out = append(out, current)
-			current = []string{}
-		}
-		current = append(current, p)
+// setColor sets the current foreground or background color, keeping the
+// text attributes.
+func (ei *escapeInterpreter) setColor(which fontEffect, color Attribute) {
+	if which == setForegroundColor {
+		ei.curFgColor = color | ei.curFgColor&AttrStyleBits
+	} else {
+		ei.curBgColor = color | ei.curBgColor&AttrStyleBits
 	}
+}
 
-	if len(current) > 0 {
-		out = append(out, current)
+// setFontEffect sets or resets the text attributes of the current foreground.
+func (ei *escapeInterpreter) setFontEffect(p int) {
+	switch fontEffect(p) {
+	case normalIntensity:
+		ei.curFgColor &^= AttrBold | AttrDim
+	case notItalic:
+		ei.curFgColor &^= AttrItalic
+	case notUnderline:
+		ei.curFgColor &^= AttrUnderline
+	case notBlink:
+		ei.curFgColor &^= AttrBlink
+	case notReverse:
+		ei.curFgColor &^= AttrReverse
+	case notStrike:
+		ei.curFgColor &^= AttrStrikeThrough
+	default:
+		ei.curFgColor |= getFontEffect(p)
 	}
+}
+
+// paletteColor returns the Attribute of the ANSI color n (0-255) for the
+// output mode of the interpreter. Output216 and OutputGrayscale don't use
+// ANSI numbers, so the nearest color of their palettes is used.
+func (ei *escapeInterpreter) paletteColor(n int) Attribute {
+	switch ei.mode {
+	case Output216, OutputGrayscale:
+		r, g, b := Get256Color(int32(n)).RGB()
+		return ei.rgbColor(r, g, b)
+	}
+	return Get256Color(int32(n))
+}
 
-	return out
+// rgbColor returns the Attribute of an RGB color for the output mode of the
+// interpreter.
+func (ei *escapeInterpreter) rgbColor(r, g, b int32) Attribute {
+	switch ei.mode {
+	case Output216:
+		return Get256Color(int32(rgbTo216(r, g, b)))
+	case OutputGrayscale:
+		return Get256Color(int32(rgbToGrayscale(r, g, b)))
+	}
+	return NewRGBColor(r, g, b)
+}
+
+// atoiDefault is strconv.Atoi, except that an empty parameter is 0.
+func atoiDefault(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errCSIParseError
+	}
+	return n, nil
 }
 
 func getFontEffect(f int) Attribute {
@@ -318,7 +479,7 @@ func getFontEffect(f int) Attribute {
 		return AttrDim
 	case italic:
 		return AttrItalic
-	case underline:
+	case underline, doubleUnderline:
 		return AttrUnderline
 	case blink:
 		return AttrBlink
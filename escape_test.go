@@ -0,0 +1,99 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"strings"
+	"testing"
+)
+
+// parseString feeds s to the interpreter and returns the runes that would
+// be printed.
+func parseString(t *testing.T, ei *escapeInterpreter, s string) string {
+	t.Helper()
+	var out []rune
+	for _, ch := range s {
+		isEscape, err := ei.parseOne(ch)
+		if err != nil {
+			t.Fatalf("parsing %q: %v", s, err)
+		}
+		if !isEscape {
+			out = append(out, ch)
+		}
+	}
+	return string(out)
+}
+
+func TestEscapeSGR(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   OutputMode
+		input  string
+		fg, bg Attribute
+	}{
+		{"basic colors", OutputNormal, "\x1b[31;42m", ColorRed, ColorGreen},
+		{"attributes kept with color", OutputNormal, "\x1b[1;4;33m", ColorYellow | AttrBold | AttrUnderline, ColorDefault},
+		{"reset intensity", OutputNormal, "\x1b[1;2;3;31m\x1b[22m", ColorRed | AttrItalic, ColorDefault},
+		{"reset attributes", OutputNormal, "\x1b[3;4;5;7;9m\x1b[23;24;25;27;29m", ColorDefault, ColorDefault},
+		{"default colors", OutputNormal, "\x1b[1;31;44m\x1b[39;49m", AttrBold, ColorDefault},
+		{"bright colors", Output256, "\x1b[91;104m", Get256Color(9), Get256Color(12)},
+		{"empty reset", OutputNormal, "\x1b[31m\x1b[m", ColorDefault, ColorDefault},
+		{"256 colors", Output256, "\x1b[38;5;196;48;5;21m", Get256Color(196), Get256Color(21)},
+		{"256 colors with colons", Output256, "\x1b[38:5:196m", Get256Color(196), ColorDefault},
+		{"rgb colors", OutputTrue, "\x1b[38;2;10;20;30;1m", NewRGBColor(10, 20, 30) | AttrBold, ColorDefault},
+		{"rgb colors with colons", OutputTrue, "\x1b[48:2::10:20:30m", ColorDefault, NewRGBColor(10, 20, 30)},
+		{"rgb colors with colons no color space", OutputTrue, "\x1b[48:2:10:20:30m", ColorDefault, NewRGBColor(10, 20, 30)},
+		{"216 mode", Output216, "\x1b[38;5;196m", Get256Color(180), ColorDefault},
+		{"malformed color dropped", OutputNormal, "\x1b[1;38;5;300;4m", AttrBold, ColorDefault},
+		{"grayscale mode", OutputGrayscale, "\x1b[38;5;255;48;5;16m", Get256Color(24), Get256Color(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ei := newEscapeInterpreter(tt.mode)
+			if out := parseString(t, ei, tt.input); out != "" {
+				t.Errorf("unexpected output %q", out)
+			}
+			if ei.curFgColor != tt.fg || ei.curBgColor != tt.bg {
+				t.Errorf("got fg %x bg %x, want fg %x bg %x", ei.curFgColor, ei.curBgColor, tt.fg, tt.bg)
+			}
+		})
+	}
+}
+
+func TestEscapeSkipsUnsupportedSequences(t *testing.T) {
+	tests := []struct {
+		input, output string
+	}{
+		{"a\x1b[?25lb", "ab"},
+		{"a\x1b]0;title\x07b", "ab"},
+		{"a\x1b]8;;http://example.com\x1b\\link\x1b]8;;\x1b\\b", "alinkb"},
+		{"a\x1b(Bb", "ab"},
+		{"a\x1b=b", "ab"},
+		{"a\x1b[2 qb", "ab"},
+		{"a\x1b[1;5Hb", "ab"},
+		{"a\x1bPq#0;2;0;0;0#1!14~\x1b\\b", "ab"},
+		{"a\x1b_payload\x07b", "ab"},
+		{"a\x1b[38;5;300mb", "ab"},
+		{"a\x1b[38;2;1;2mb", "ab"},
+		{"a\x1b[48;2;1;2;256mb", "ab"},
+		{"a\x1b[38;7mb", "ab"},
+		{"a\x1b[38:5:300mb", "ab"},
+	}
+
+	for _, tt := range tests {
+		ei := newEscapeInterpreter(OutputNormal)
+		if out := parseString(t, ei, tt.input); out != tt.output {
+			t.Errorf("parsing %q: got %q, want %q", tt.input, out, tt.output)
+		}
+	}
+
+	// an unterminated string ends after maxStringLen runes
+	ei := newEscapeInterpreter(OutputNormal)
+	input := "a\x1b]0;" + strings.Repeat("x", maxStringLen-2) + "text"
+	if out := parseString(t, ei, input); out != "atext" {
+		t.Errorf("got %q after an unterminated string, want %q", out, "atext")
+	}
+}
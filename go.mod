@@ -5,5 +5,6 @@ go 1.13
 require (
 	github.com/gdamore/tcell/v2 v2.4.0
 	github.com/mattn/go-runewidth v0.0.10
+	github.com/rivo/uniseg v0.1.0
 	golang.org/x/text v0.3.3 // indirect
 )
@@ -0,0 +1,108 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+// extendedBy reports whether r continues the grapheme cluster of the cell
+// instead of starting a new one.
+func (c cell) extendedBy(r rune) bool {
+	if c.chr == 0 || c.chr == widePlaceholder {
+		return false
+	}
+	last := c.chr
+	if len(c.comb) > 0 {
+		last = c.comb[len(c.comb)-1]
+	}
+	if r < 0x300 && last < 0x300 {
+		// fast path: there is no extending or prepended character before
+		// U+0300
+		return false
+	}
+	return uniseg.GraphemeClusterCount(string(c.runes())+string(r)) == 1
+}
+
+// withRune returns the cell with r added to its grapheme cluster. The runes
+// are copied, as cells are shared by the copies of the lines.
+func (c cell) withRune(r rune) cell {
+	comb := make([]rune, len(c.comb), len(c.comb)+1)
+	copy(comb, c.comb)
+	c.comb = append(comb, r)
+	return c
+}
+
+// runes returns the runes of the grapheme cluster of the cell.
+func (c cell) runes() []rune {
+	if len(c.comb) == 0 {
+		return []rune{c.chr}
+	}
+	return append([]rune{c.chr}, c.comb...)
+}
+
+// joinCluster adds r to the grapheme cluster of the cell before x on the
+// line y of the buffer, if it continues it. It returns false if r starts a
+// new cluster.
+func (v *View) joinCluster(x, y int, r rune) bool {
+	if y < 0 || y >= len(v.lines) || x <= 0 || x > len(v.lines[y]) {
+		return false
+	}
+	c := v.lines[y][x-1]
+	if !c.extendedBy(r) {
+		return false
+	}
+	// the line is replaced instead of modified in place, see View.edit
+	line := append([]cell(nil), v.lines[y]...)
+	line[x-1] = c.withRune(r)
+	v.lines[y] = line
+	v.tainted = true
+	return true
+}
+
+// clusterWidth returns the number of columns of a grapheme cluster: the
+// width of its first rune, or 2 for emoji presented as such by a variation
+// selector and for flags.
+func clusterWidth(chr rune, comb []rune) int {
+	if len(comb) > 0 {
+		if chr >= 0x1f1e6 && chr <= 0x1f1ff {
+			// regional indicators pair as flags
+			return 2
+		}
+		for _, r := range comb {
+			if r == 0xfe0f {
+				return 2
+			}
+		}
+	}
+	return runewidth.RuneWidth(chr)
+}
+
+// runesToCells splits runes into grapheme clusters, one per cell, with the
+// given colors.
+func runesToCells(runes []rune, fg, bg Attribute) []cell {
+	cells := make([]cell, 0, len(runes))
+	for _, r := range runes {
+		if n := len(cells); n > 0 && cells[n-1].extendedBy(r) {
+			cells[n-1] = cells[n-1].withRune(r)
+			continue
+		}
+		cells = append(cells, cell{chr: r, fgColor: fg, bgColor: bg})
+	}
+	return cells
+}
+
+// graphemeBounds returns the indexes of runes where the grapheme clusters of
+// the runes start, followed by the length of runes.
+func graphemeBounds(runes []rune) []int {
+	bounds := make([]int, 0, len(runes)+1)
+	i := 0
+	for _, c := range runesToCells(runes, 0, 0) {
+		bounds = append(bounds, i)
+		i += 1 + len(c.comb)
+	}
+	return append(bounds, i)
+}
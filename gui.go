@@ -8,6 +8,10 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
 )
 
 // OutputMode represents an output mode, which determines how colors
@@ -58,6 +62,17 @@ const (
 	// input and the option to retrieve the current conent
 	// See: SendKeyToSimulatedScreen, GetContentOfSimulatedScreen
 	OutputSimulator
+
+	// OutputAuto selects the best mode supported by the terminal, from the
+	// COLORTERM, TERM and NO_COLOR environment variables and the number of
+	// colors of the terminfo entry. The selected mode can be forced with
+	// the GOCUI_OUTPUT environment variable and is returned by
+	// Gui.OutputMode.
+	OutputAuto
+
+	// OutputNoColor draws the text attributes, like bold or reverse,
+	// without any color. It is selected by OutputAuto when NO_COLOR is set.
+	OutputNoColor
 )
 
 // Gui represents the whole User Interface, including the views, layouts
@@ -79,6 +94,37 @@ type Gui struct {
 	// The position of the mouse
 	mouseX, mouseY int
 
+	// The position where the current drag started and the number of
+	// consecutive clicks of the last button press
+	dragX, dragY int
+	clickCount   int
+
+	// mouseState is owned by the event polling goroutine
+	mouseState mouseState
+
+	// frameDrag is set while a view is moved or resized with the mouse
+	frameDrag *frameDrag
+
+	// scrollDrag is set while a scrollbar thumb is dragged with the mouse
+	scrollDrag *scrollbarDrag
+
+	// theme is the theme set with SetTheme
+	theme Theme
+
+	// textSelect is set while text is selected with the mouse
+	textSelect *textSelect
+
+	// completion is set while the completion popup is displayed
+	completion *completion
+
+	// clipboard is the text copied last when LocalClipboard is set
+	clipboard string
+
+	// clipboardQueue holds the texts copied with CopyToClipboard, which are
+	// sent to the terminal after the next flush of the screen
+	clipboardMutex sync.Mutex
+	clipboardQueue []string
+
 	// BgColor and FgColor allow to configure the background and foreground
 	// colors of the GUI.
 	BgColor, FgColor, FrameColor Attribute
@@ -97,6 +143,19 @@ type Gui struct {
 	// If Mouse is true then mouse events will be enabled.
 	Mouse bool
 
+	// If MouseMotion is true then mouse motion without any pressed button
+	// is reported as MouseMove events. It must be set before MainLoop is
+	// called.
+	MouseMotion bool
+
+	// DoubleClickInterval is the maximum delay between two clicks for them
+	// to be counted as a double click (or triple click, etc.).
+	DoubleClickInterval time.Duration
+
+	// MouseScrollLines is the number of lines (or columns) scrolled by one
+	// mouse wheel notch in views with MouseScroll enabled.
+	MouseScrollLines int
+
 	// If InputEsc is true, when ESC sequence is in the buffer and it doesn't
 	// match any known sequence, ESC means KeyEsc.
 	InputEsc bool
@@ -108,6 +167,23 @@ type Gui struct {
 	// SupportOverlaps is true when we allow for view edges to overlap with other
 	// view edges
 	SupportOverlaps bool
+
+	// OnViewRectChanged is called when the user finished moving or resizing
+	// a Draggable or Resizable view with the mouse. It receives the new
+	// dimensions of the view, so the application can save its layout.
+	OnViewRectChanged func(v *View, x0, y0, x1, y1 int) error
+
+	// If LocalClipboard is true, the text copied with CopyToClipboard is
+	// also kept in memory and returned by Clipboard, so that it can be pasted
+	// in the application when the terminal doesn't support OSC 52.
+	LocalClipboard bool
+
+	// ClipboardWriter, if not nil, replaces the OSC 52 escape sequence sent
+	// to the terminal by CopyToClipboard, e.g. to use the clipboard of the
+	// system where there is no /dev/tty, like on Windows. It is called from
+	// the main loop, after the screen is flushed, and its error is returned
+	// by MainLoop.
+	ClipboardWriter func(text string) error
 }
 
 // NewGui returns a new Gui object with a given output mode.
@@ -127,6 +203,9 @@ func NewGui(mode OutputMode, supportOverlaps bool) (*Gui, error) {
 
 	g := &Gui{}
 
+	if mode == OutputAuto {
+		mode = autoOutputMode()
+	}
 	g.outputMode = mode
 
 	g.stop = make(chan struct{})
@@ -145,6 +224,9 @@ func NewGui(mode OutputMode, supportOverlaps bool) (*Gui, error) {
 	}
 
 	g.mouseX, g.mouseY = -1, -1
+	g.dragX, g.dragY = -1, -1
+	g.DoubleClickInterval = 400 * time.Millisecond
+	g.MouseScrollLines = 1
 	g.BgColor, g.FgColor, g.FrameColor = ColorDefault, ColorDefault, ColorDefault
 	g.SelBgColor, g.SelFgColor, g.SelFrameColor = ColorDefault, ColorDefault, ColorDefault
 
@@ -155,6 +237,12 @@ func NewGui(mode OutputMode, supportOverlaps bool) (*Gui, error) {
 	return g, nil
 }
 
+// OutputMode returns the output mode of the GUI. When the GUI was created
+// with OutputAuto, it is the mode which was selected for the terminal.
+func (g *Gui) OutputMode() OutputMode {
+	return g.outputMode
+}
+
 // Close finalizes the library. It should be called after a successful
 // initialization and when gocui is not needed anymore.
 func (g *Gui) Close() {
@@ -175,6 +263,19 @@ func (g *Gui) MousePosition() (x, y int) {
 	return g.mouseX, g.mouseY
 }
 
+// MouseDragStart returns the position where the current mouse drag started.
+// If no button is held down MouseDragStart will return -1, -1.
+func (g *Gui) MouseDragStart() (x, y int) {
+	return g.dragX, g.dragY
+}
+
+// MouseClickCount returns the number of consecutive clicks of the last mouse
+// button press, e.g. 2 for a double click. Clicks are consecutive when they
+// happen at the same position within DoubleClickInterval.
+func (g *Gui) MouseClickCount() int {
+	return g.clickCount
+}
+
 // SetRune writes a rune at the given point, relative to the top-left
 // corner of the terminal. It checks if the position is valid and applies
 // the given colors.
@@ -182,7 +283,7 @@ func (g *Gui) SetRune(x, y int, ch rune, fgColor, bgColor Attribute) error {
 	if x < 0 || y < 0 || x >= g.maxX || y >= g.maxY {
 		return errors.New("invalid point")
 	}
-	tcellSetCell(x, y, ch, fgColor, bgColor, g.outputMode)
+	tcellSetCell(x, y, ch, nil, fgColor, bgColor, g.outputMode)
 	return nil
 }
 
@@ -210,10 +311,13 @@ func (g *Gui) SetView(name string, x0, y0, x1, y1 int, overlaps byte) (*View, er
 	}
 
 	if v, err := g.View(name); err == nil {
-		v.x0 = x0
-		v.y0 = y0
-		v.x1 = x1
-		v.y1 = y1
+		// keep the dimensions set by the user with the mouse
+		if !v.rectOverride {
+			v.x0 = x0
+			v.y0 = y0
+			v.x1 = x1
+			v.y1 = y1
+		}
 		v.tainted = true
 		return v, nil
 	}
@@ -221,6 +325,9 @@ func (g *Gui) SetView(name string, x0, y0, x1, y1 int, overlaps byte) (*View, er
 	v := g.newView(name, x0, y0, x1, y1, g.outputMode)
 	v.BgColor, v.FgColor = g.BgColor, g.FgColor
 	v.SelBgColor, v.SelFgColor = g.SelBgColor, g.SelFgColor
+	if g.theme != nil {
+		v.applyTheme()
+	}
 	v.Overlaps = overlaps
 	g.views = append(g.views, v)
 	return v, ErrUnknownView
@@ -485,13 +592,17 @@ func (g *Gui) MainLoop() error {
 			case <-g.stop:
 				return
 			default:
-				g.gEvents <- pollEvent()
+				g.gEvents <- g.pollEvent()
 			}
 		}
 	}()
 
 	if g.Mouse {
-		screen.EnableMouse()
+		if g.MouseMotion {
+			screen.EnableMouse(tcell.MouseMotionEvents)
+		} else {
+			screen.EnableMouse(tcell.MouseDragEvents)
+		}
 	}
 
 	if err := g.flush(); err != nil {
@@ -588,24 +699,7 @@ func (g *Gui) flush() error {
 			continue
 		}
 		if v.Frame {
-			var fgColor, bgColor, frameColor Attribute
-			if g.Highlight && v == g.currentView {
-				fgColor = g.SelFgColor
-				bgColor = g.SelBgColor
-				frameColor = g.SelFrameColor
-			} else {
-				bgColor = g.BgColor
-				if v.TitleColor != ColorDefault {
-					fgColor = v.TitleColor
-				} else {
-					fgColor = g.FgColor
-				}
-				if v.FrameColor != ColorDefault {
-					frameColor = v.FrameColor
-				} else {
-					frameColor = g.FrameColor
-				}
-			}
+			fgColor, bgColor, frameColor := g.frameColors(v)
 
 			if err := g.drawFrameEdges(v, frameColor, bgColor); err != nil {
 				return err
@@ -627,9 +721,41 @@ func (g *Gui) flush() error {
 		if err := g.draw(v); err != nil {
 			return err
 		}
+		if v.Frame && (v.VerticalScrollbar || v.HorizontalScrollbar) {
+			_, bgColor, frameColor := g.frameColors(v)
+			g.drawScrollbars(v, frameColor, bgColor)
+		}
 	}
 	screen.Show()
-	return nil
+	return g.flushClipboard()
+}
+
+// frameColors returns the colors used to draw the title, the background and
+// the edges of the frame of a view.
+func (g *Gui) frameColors(v *View) (fgColor, bgColor, frameColor Attribute) {
+	if g.Highlight && v == g.currentView {
+		fgColor, bgColor, frameColor = g.SelFgColor, g.SelBgColor, g.SelFrameColor
+		if s, ok := v.roleStyles[RoleFocusedTitle]; ok {
+			fgColor = s.Fg
+		}
+		if s, ok := v.roleStyles[RoleFocusedFrame]; ok {
+			frameColor, bgColor = s.Fg, s.Bg
+		}
+		return fgColor, bgColor, frameColor
+	}
+
+	bgColor = g.BgColor
+	if v.TitleColor != ColorDefault {
+		fgColor = v.TitleColor
+	} else {
+		fgColor = g.FgColor
+	}
+	if v.FrameColor != ColorDefault {
+		frameColor = v.FrameColor
+	} else {
+		frameColor = g.FrameColor
+	}
+	return fgColor, bgColor, frameColor
 }
 
 func (g *Gui) clear(fg, bg Attribute) (int, int) {
@@ -685,6 +811,57 @@ func (g *Gui) drawFrameEdges(v *View, fgColor, bgColor Attribute) error {
 	return nil
 }
 
+// drawScrollbars draws the scrollbars of a view over the right and bottom
+// edges of its frame.
+func (g *Gui) drawScrollbars(v *View, fgColor, bgColor Attribute) {
+	thumb, track := '█', rune(0)
+	if len(v.ScrollbarRunes) > 0 {
+		thumb = v.ScrollbarRunes[0]
+		if len(v.ScrollbarRunes) > 1 {
+			track = v.ScrollbarRunes[1]
+		}
+	} else if g.ASCII {
+		thumb = '#'
+	}
+	thumbFg, thumbBg := fgColor, bgColor
+	if v.ScrollbarFgColor != ColorDefault {
+		thumbFg = v.ScrollbarFgColor
+	}
+	if v.ScrollbarBgColor != ColorDefault {
+		thumbBg = v.ScrollbarBgColor
+	}
+
+	setRune := func(x, y int, ch rune, fg, bg Attribute) {
+		if x >= 0 && y >= 0 && x < g.maxX && y < g.maxY {
+			tcellSetCell(x, y, ch, nil, fg, bg, g.outputMode)
+		}
+	}
+
+	maxX, maxY := v.Size()
+	if v.VerticalScrollbar {
+		if pos, size, ok := v.scrollbarThumb(true); ok {
+			for i := 0; i < maxY; i++ {
+				if i >= pos && i < pos+size {
+					setRune(v.x1, v.y0+1+i, thumb, thumbFg, thumbBg)
+				} else if track != 0 {
+					setRune(v.x1, v.y0+1+i, track, fgColor, bgColor)
+				}
+			}
+		}
+	}
+	if v.HorizontalScrollbar {
+		if pos, size, ok := v.scrollbarThumb(false); ok {
+			for i := 0; i < maxX; i++ {
+				if i >= pos && i < pos+size {
+					setRune(v.x0+1+v.gutterWidth()+i, v.y1, thumb, thumbFg, thumbBg)
+				} else if track != 0 {
+					setRune(v.x0+1+v.gutterWidth()+i, v.y1, track, fgColor, bgColor)
+				}
+			}
+		}
+	}
+}
+
 func cornerRune(index byte) rune {
 	return []rune{' ', '│', '│', '│', '─', '┘', '┐', '┤', '─', '└', '┌', '├', '├', '┴', '┬', '┼'}[index]
 }
@@ -866,7 +1043,7 @@ func (g *Gui) draw(v *View) error {
 		return completed(true)
 	}
 
-	x := curview.x0 + cursorX + 1 - curview.ox
+	x := curview.x0 + curview.gutterWidth() + cursorX + 1 - curview.ox
 	y := curview.y0 + cursorY + 1 - curview.oy
 	screen.ShowCursor(x, y)
 
@@ -879,6 +1056,10 @@ func (g *Gui) draw(v *View) error {
 func (g *Gui) onKey(ev *gocuiEvent) error {
 	switch ev.Type {
 	case eventKey:
+		handled, err := g.completionKey(ev)
+		if err != nil || handled {
+			return err
+		}
 		matched, err := g.execKeybindings(g.currentView, ev)
 		if err != nil {
 			return err
@@ -886,25 +1067,137 @@ func (g *Gui) onKey(ev *gocuiEvent) error {
 		if matched {
 			break
 		}
+		if handled, err := g.tabCompletion(ev); err != nil || handled {
+			return err
+		}
 		if g.currentView != nil && g.currentView.Editable && g.currentView.Editor != nil {
 			g.currentView.Editor.Edit(g.currentView, Key(ev.Key), ev.Ch, Modifier(ev.Mod))
+			return g.updateCompletion(g.currentView, ev.Ch)
 		}
 	case eventMouse:
 		mx, my := ev.MouseX, ev.MouseY
 		g.mouseX = mx
 		g.mouseY = my
+		switch Key(ev.Key) {
+		case MouseDrag:
+			return g.onMouseDrag(ev)
+		case MouseMove:
+			v, _ := g.ViewByPosition(mx, my)
+			if _, err := g.execKeybindings(v, ev); err != nil {
+				return err
+			}
+			return nil
+		case MouseLeft, MouseRight, MouseMiddle:
+			g.dragX, g.dragY = mx, my
+			g.clickCount = ev.Clicks
+			if Key(ev.Key) == MouseLeft && (g.startScrollbarDrag(mx, my) || g.startFrameDrag(mx, my)) {
+				return nil
+			}
+			if Key(ev.Key) == MouseLeft {
+				g.startTextSelect(mx, my, ev.Clicks)
+			}
+		case MouseRelease:
+			if g.scrollDrag != nil {
+				g.scrollDrag = nil
+				g.dragX, g.dragY = -1, -1
+				return nil
+			}
+			if g.frameDrag != nil {
+				g.dragX, g.dragY = -1, -1
+				return g.endFrameDrag()
+			}
+			if g.textSelect != nil {
+				g.endTextSelect()
+			}
+		}
 		v, err := g.ViewByPosition(mx, my)
 		if err != nil {
 			break
 		}
-		if err := v.SetCursor(mx-v.x0-1, my-v.y0-1); err != nil {
+		if v.MouseScroll && isMouseWheel(Key(ev.Key)) {
+			return g.onMouseWheel(v, ev)
+		}
+		if v.Editable {
+			// the cursor of editable views is a position in the buffer
+			pos := v.bufferPosAt(mx-v.x0-1-v.gutterWidth(), my-v.y0-1)
+			if err := v.SetCursor(pos.x, pos.y); err != nil {
+				return err
+			}
+		} else if err := v.SetCursor(max(mx-v.x0-1-v.gutterWidth(), 0), my-v.y0-1); err != nil {
 			return err
 		}
+		if Key(ev.Key) == MouseLeft && ev.Clicks >= 2 {
+			double := *ev
+			double.Key = MouseLeftDouble
+			matched, err := g.execKeybindings(v, &double)
+			if err != nil || matched {
+				return err
+			}
+		}
 		if _, err := g.execKeybindings(v, ev); err != nil {
 			return err
 		}
 	}
 
+	if ev.Type == eventMouse && Key(ev.Key) == MouseRelease {
+		g.dragX, g.dragY = -1, -1
+	}
+
+	return nil
+}
+
+// onMouseWheel scrolls the view under the pointer, unless the wheel event is
+// handled by a keybinding.
+func (g *Gui) onMouseWheel(v *View, ev *gocuiEvent) error {
+	matched, err := g.execKeybindings(v, ev)
+	if err != nil || matched {
+		return err
+	}
+
+	lines := g.MouseScrollLines
+	if lines < 1 {
+		lines = 1
+	}
+	switch Key(ev.Key) {
+	case MouseWheelUp:
+		v.ScrollUp(lines)
+	case MouseWheelDown:
+		v.ScrollDown(lines)
+	case MouseWheelLeft:
+		v.ScrollLeft(lines)
+	case MouseWheelRight:
+		v.ScrollRight(lines)
+	}
+	return nil
+}
+
+// isMouseWheel reports whether the key is one of the mouse wheel keys.
+func isMouseWheel(k Key) bool {
+	return k == MouseWheelUp || k == MouseWheelDown || k == MouseWheelLeft || k == MouseWheelRight
+}
+
+// onMouseDrag handles mouse motion while a button is held down. The event is
+// dispatched to the view where the drag started, so it keeps receiving drag
+// events when the pointer leaves it.
+func (g *Gui) onMouseDrag(ev *gocuiEvent) error {
+	if g.scrollDrag != nil {
+		g.updateScrollbarDrag(ev.MouseX, ev.MouseY)
+		return nil
+	}
+	if g.frameDrag != nil {
+		g.updateFrameDrag(ev.MouseX, ev.MouseY)
+		return nil
+	}
+	if g.dragX < 0 {
+		g.dragX, g.dragY = ev.StartX, ev.StartY
+	}
+	if g.textSelect != nil {
+		g.updateTextSelect(ev.MouseX, ev.MouseY)
+	}
+	v, _ := g.ViewByPosition(g.dragX, g.dragY)
+	if _, err := g.execKeybindings(v, ev); err != nil {
+		return err
+	}
 	return nil
 }
 
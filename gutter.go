@@ -0,0 +1,218 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "strconv"
+
+// LineNumberMode is the kind of line numbers shown in the gutter of a view.
+type LineNumberMode int
+
+// Line number modes.
+const (
+	// LineNumbersNone shows no line numbers.
+	LineNumbersNone LineNumberMode = iota
+
+	// LineNumbersAbsolute shows the number of each line, starting at 1.
+	LineNumbersAbsolute
+
+	// LineNumbersRelative shows the distance of each line to the line of
+	// the cursor, and the number of the line of the cursor.
+	LineNumbersRelative
+)
+
+const (
+	// signColumnWidth is the number of columns of the sign column.
+	signColumnWidth = 2
+
+	// minNumberWidth is the minimum number of digits of line numbers, so
+	// that the gutter doesn't grow with the first lines written.
+	minNumberWidth = 3
+)
+
+// A Sign is shown in the sign column of a view, before a line, e.g. to mark
+// breakpoints or errors.
+type Sign struct {
+	// Text is drawn in the two columns of the sign column, e.g. "●" or
+	// ">>". It is cut if it is wider.
+	Text string
+
+	// Style is the style of the text. The colors of the view are used
+	// when it has none.
+	Style Style
+}
+
+// SetSign shows a sign before the line y of the buffer. The sign column is
+// shown when SignColumn is set.
+func (v *View) SetSign(y int, sign Sign) {
+	if v.signs == nil {
+		v.signs = make(map[int]Sign)
+	}
+	v.signs[y] = sign
+}
+
+// Sign returns the sign shown before the line y of the buffer, and false if
+// there is none.
+func (v *View) Sign(y int) (Sign, bool) {
+	sign, ok := v.signs[y]
+	return sign, ok
+}
+
+// ClearSign removes the sign shown before the line y of the buffer.
+func (v *View) ClearSign(y int) {
+	delete(v.signs, y)
+}
+
+// ClearSigns removes all the signs of the view.
+func (v *View) ClearSigns() {
+	v.signs = nil
+}
+
+// shiftSigns keeps the signs on their lines when n lines are inserted before
+// the line y, or -n lines are deleted from the line y. The signs of the
+// deleted lines are removed.
+func (v *View) shiftSigns(y, n int) {
+	if len(v.signs) == 0 || n == 0 {
+		return
+	}
+	signs := make(map[int]Sign, len(v.signs))
+	for line, sign := range v.signs {
+		switch {
+		case line < y:
+			signs[line] = sign
+		case n < 0 && line < y-n:
+			// deleted
+		default:
+			signs[line+n] = sign
+		}
+	}
+	v.signs = signs
+}
+
+// gutterWidth returns the number of columns of the gutter of the view, made
+// of the sign column and the line numbers followed by a space, or 0 if it
+// doesn't leave room for any text.
+func (v *View) gutterWidth() int {
+	w := 0
+	if v.SignColumn {
+		w += signColumnWidth
+	}
+	if v.LineNumbers != LineNumbersNone {
+		w += v.numberWidth() + 1
+	}
+	if w >= v.x1-v.x0-1 {
+		return 0
+	}
+	return w
+}
+
+// numberWidth returns the number of digits of the line numbers.
+func (v *View) numberWidth() int {
+	return max(len(strconv.Itoa(len(v.lines))), minNumberWidth)
+}
+
+// gutterLines returns the index of the buffer line shown by each of the
+// height lines of the view from the origin, or -1 for the continuation lines
+// of wrapped lines and the lines after the end of the buffer.
+func (v *View) gutterLines(height int) []int {
+	rows := make([]int, 0, height)
+	if !v.Wrap {
+		for y := v.oy; y < v.oy+height; y++ {
+			if y >= len(v.lines) {
+				rows = append(rows, -1)
+			} else {
+				rows = append(rows, y)
+			}
+		}
+		return rows
+	}
+
+	row := 0
+	for y, line := range v.lines {
+		for continuation := false; ; continuation = true {
+			_, _, end := v.takeLine(&line, continuation)
+			if row >= v.oy {
+				if continuation {
+					rows = append(rows, -1)
+				} else {
+					rows = append(rows, y)
+				}
+				if len(rows) == height {
+					return rows
+				}
+			}
+			row++
+			if end {
+				break
+			}
+		}
+	}
+	for len(rows) < height {
+		rows = append(rows, -1)
+	}
+	return rows
+}
+
+// gutterCells returns the cells of the gutter before the line y of the
+// buffer, or blank cells if y is -1.
+func (v *View) gutterCells(y, width int) []cell {
+	cells := make([]cell, 0, width)
+	if v.SignColumn {
+		if sign, ok := v.signs[y]; ok && y >= 0 {
+			for _, c := range styledCells(sign.Text, sign.Style) {
+				if lineWidth(cells, defaultTabWidth)+cellWidth(c) > signColumnWidth {
+					break
+				}
+				cells = append(cells, c)
+			}
+		}
+		for lineWidth(cells, defaultTabWidth) < signColumnWidth {
+			cells = append(cells, cell{chr: ' '})
+		}
+	}
+	if v.LineNumbers != LineNumbersNone && y >= 0 {
+		n, style := y+1, v.roleStyleOr(RoleLineNumber, Style{Fg: AttrDim})
+		if y == v.cy {
+			style = v.roleStyleOr(RoleCurrentLineNumber, Style{})
+		} else if v.LineNumbers == LineNumbersRelative {
+			n = y - v.cy
+			if n < 0 {
+				n = -n
+			}
+		}
+		text := strconv.Itoa(n)
+		for i := len(text); i < v.numberWidth(); i++ {
+			text = " " + text
+		}
+		cells = append(cells, styledCells(text, style)...)
+	}
+	for lineWidth(cells, defaultTabWidth) < width {
+		cells = append(cells, cell{chr: ' '})
+	}
+	return cells
+}
+
+// drawGutter draws the gutter of the view at its left, with the sign and the
+// number of the buffer lines shown by the view.
+func (v *View) drawGutter() {
+	width := v.gutterWidth()
+	if width == 0 {
+		return
+	}
+	_, maxY := v.Size()
+	for row, y := range v.gutterLines(maxY) {
+		x := v.x0 + 1
+		for _, c := range v.gutterCells(y, width) {
+			fgColor, bgColor := c.fgColor, c.bgColor
+			if fgColor&AttrColorBits == ColorDefault {
+				fgColor |= v.FgColor
+			}
+			if bgColor&AttrColorBits == ColorDefault {
+				bgColor |= v.BgColor
+			}
+			tcellSetCell(x, v.y0+1+row, c.chr, c.comb, fgColor, bgColor, v.outMode)
+			x += cellWidth(c)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"go/scanner"
+	"go/token"
+)
+
+// JSONHighlighter is a Styler highlighting JSON: the strings, the object
+// keys, the numbers and the literals true, false and null.
+var JSONHighlighter Styler = StylerFunc(highlightJSON)
+
+// GoHighlighter is a Styler highlighting Go source code: the keywords, the
+// strings, the numbers and the comments. Each line is scanned on its own, so
+// the lines inside of multi-line comments and raw strings are not
+// highlighted.
+var GoHighlighter Styler = StylerFunc(highlightGo)
+
+// tokenStyle returns the style of the tokens of a role for the view.
+func tokenStyle(v *View, role Role) Style {
+	switch role {
+	case RoleKeyword:
+		return v.roleStyleOr(role, Style{Fg: ColorMagenta})
+	case RoleString:
+		return v.roleStyleOr(role, Style{Fg: ColorGreen})
+	case RoleNumber:
+		return v.roleStyleOr(role, Style{Fg: ColorCyan})
+	case RoleComment:
+		return v.roleStyleOr(role, Style{Fg: AttrDim})
+	case RoleProperty:
+		return v.roleStyleOr(role, Style{Fg: ColorBlue})
+	}
+	return v.RoleStyle(role)
+}
+
+// highlightJSON styles a line of JSON.
+func highlightJSON(v *View, y int, line string) []StyleSpan {
+	var spans []StyleSpan
+	add := func(start, end int, role Role) {
+		spans = append(spans, StyleSpan{Start: start, End: end, Style: tokenStyle(v, role)})
+	}
+
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(line))
+			// a string followed by a colon is an object key
+			next := end
+			for next < len(line) && (line[next] == ' ' || line[next] == '\t') {
+				next++
+			}
+			if next < len(line) && line[next] == ':' {
+				add(i, end, RoleProperty)
+			} else {
+				add(i, end, RoleString)
+			}
+			i = end
+		case c == '-' || c >= '0' && c <= '9':
+			end := i + 1
+			for end < len(line) && isJSONNumberByte(line[end]) {
+				end++
+			}
+			add(i, end, RoleNumber)
+			i = end
+		case c >= 'a' && c <= 'z':
+			end := i + 1
+			for end < len(line) && line[end] >= 'a' && line[end] <= 'z' {
+				end++
+			}
+			switch line[i:end] {
+			case "true", "false", "null":
+				add(i, end, RoleKeyword)
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	return spans
+}
+
+// isJSONNumberByte reports whether c can continue a JSON number.
+func isJSONNumberByte(c byte) bool {
+	return c >= '0' && c <= '9' || c == '.' || c == 'e' || c == 'E' || c == '+' || c == '-'
+}
+
+// highlightGo styles a line of Go source code with the tokens of go/scanner.
+func highlightGo(v *View, y int, line string) []StyleSpan {
+	src := []byte(line)
+	file := token.NewFileSet().AddFile("", -1, len(src))
+	var s scanner.Scanner
+	// errors, like unterminated strings or comments, are ignored
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	var spans []StyleSpan
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		var role Role
+		switch {
+		case tok.IsKeyword():
+			role = RoleKeyword
+		case tok == token.STRING, tok == token.CHAR:
+			role = RoleString
+		case tok == token.INT, tok == token.FLOAT, tok == token.IMAG:
+			role = RoleNumber
+		case tok == token.COMMENT:
+			role = RoleComment
+		default:
+			continue
+		}
+		start := file.Offset(pos)
+		spans = append(spans, StyleSpan{Start: start, End: start + len(lit), Style: tokenStyle(v, role)})
+	}
+	return spans
+}
@@ -0,0 +1,422 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Input is a single-line text input widget. The text scrolls horizontally
+// when it doesn't fit in the view. Enter submits the text and Esc cancels the
+// input, Up and Down browse the history of the submitted texts.
+//
+// Create it from a manager:
+//
+//	in := gocui.NewInput("prompt")
+//	in.Placeholder = "Search"
+//	in.OnSubmit = func(g *gocui.Gui, text string) error {
+//		return search(text)
+//	}
+//	g.SetManagerFunc(func(g *gocui.Gui) error {
+//		maxX, maxY := g.Size()
+//		_, err := in.SetView(g, 0, maxY-3, maxX-1, maxY-1)
+//		if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+//			return err
+//		}
+//		return nil
+//	})
+type Input struct {
+	name string
+	v    *View
+
+	text   []rune
+	pos    int // cursor position in text
+	offset int // first rune of text displayed
+	err    error
+
+	// subtitle of the view, replaced by the error in views of a single line
+	subtitle    string
+	errSubtitle bool
+
+	history     []string
+	historyPos  int
+	draft       string
+	historyFile string
+
+	// Placeholder is displayed, with the muted style, when the input is
+	// empty.
+	Placeholder string
+
+	// MaxLength is the maximum number of characters of the text, 0 for no
+	// limit.
+	MaxLength int
+
+	// MaxHistory is the maximum number of entries kept in the history, 0 for
+	// no limit.
+	MaxHistory int
+
+	// Validate, if not nil, is called when the text changes. The error it
+	// returns is displayed under the text, or as the subtitle of the view
+	// if it has a single line, and prevents the text from being submitted.
+	Validate func(text string) error
+
+	// OnSubmit is called when Enter is pressed and the text is valid. The
+	// text is then added to the history and the input is cleared.
+	OnSubmit func(g *Gui, text string) error
+
+	// OnCancel is called when Esc is pressed.
+	OnCancel func(g *Gui) error
+}
+
+// NewInput returns an Input displayed in a view with the given name.
+func NewInput(name string) *Input {
+	return &Input{name: name}
+}
+
+// SetView creates or updates the view of the input, like Gui.SetView. When
+// the view is created, e.g. by the first call or after it was deleted, it
+// returns ErrUnknownView along with the view, so it can be initialized, and
+// sets the keybindings of the input on the view.
+func (in *Input) SetView(g *Gui, x0, y0, x1, y1 int) (*View, error) {
+	v, err := g.SetView(in.name, x0, y0, x1, y1, 0)
+	if err != nil && !errors.Is(err, ErrUnknownView) {
+		return nil, err
+	}
+
+	if err != nil || v != in.v {
+		in.v = v
+		v.Editable = true
+		v.Editor = in
+		v.Wrap = false
+		v.Autoscroll = false
+		bindings := []struct {
+			key     Key
+			handler func(*Gui, *View) error
+		}{
+			{KeyEnter, in.submit},
+			{KeyEsc, in.cancel},
+			{KeyArrowUp, in.historyUp},
+			{KeyArrowDown, in.historyDown},
+		}
+		g.DeleteKeybindings(in.name)
+		for _, b := range bindings {
+			if err := g.SetKeybinding(in.name, b.key, ModNone, b.handler); err != nil {
+				return nil, err
+			}
+		}
+		in.render()
+		return v, ErrUnknownView
+	}
+
+	in.render()
+	return v, nil
+}
+
+// Text returns the text of the input.
+func (in *Input) Text() string {
+	return string(in.text)
+}
+
+// SetText replaces the text of the input, truncated to MaxLength, and moves
+// the cursor to its end.
+func (in *Input) SetText(text string) {
+	in.text = []rune(text)
+	if in.MaxLength > 0 && len(in.text) > in.MaxLength {
+		in.text = in.text[:in.MaxLength]
+	}
+	in.pos = len(in.text)
+	in.changed()
+}
+
+// Err returns the error returned by Validate for the current text.
+func (in *Input) Err() error {
+	return in.err
+}
+
+// History returns the submitted texts, from the oldest to the newest.
+func (in *Input) History() []string {
+	return in.history
+}
+
+// AddHistory adds a text at the end of the history, unless it is empty or
+// the same as the last entry. If LoadHistory was called, the text is also
+// appended to the history file.
+func (in *Input) AddHistory(text string) error {
+	in.historyPos = len(in.history)
+	if text == "" || len(in.history) > 0 && in.history[len(in.history)-1] == text {
+		return nil
+	}
+	in.history = append(in.history, text)
+	trimmed := in.trimHistory()
+	in.historyPos = len(in.history)
+
+	if in.historyFile == "" {
+		return nil
+	}
+	if trimmed {
+		// the file is rewritten so that it doesn't grow beyond MaxHistory
+		return ioutil.WriteFile(in.historyFile, []byte(strings.Join(in.history, "\n")+"\n"), 0600)
+	}
+	f, err := os.OpenFile(in.historyFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(text + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadHistory reads the history from a file, one entry per line, and
+// appends the texts submitted afterwards to it. A missing file is not an
+// error, it is created by the first submission.
+func (in *Input) LoadHistory(path string) error {
+	in.historyFile = path
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	in.history = in.history[:0]
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if line := s.Text(); line != "" {
+			in.history = append(in.history, line)
+		}
+	}
+	in.trimHistory()
+	in.historyPos = len(in.history)
+	return s.Err()
+}
+
+// trimHistory removes the oldest entries beyond MaxHistory. It returns false
+// if there is none.
+func (in *Input) trimHistory() bool {
+	if in.MaxHistory > 0 && len(in.history) > in.MaxHistory {
+		in.history = in.history[len(in.history)-in.MaxHistory:]
+		return true
+	}
+	return false
+}
+
+// Edit handles a key press in the view of the input. It implements the
+// Editor interface.
+func (in *Input) Edit(v *View, key Key, ch rune, mod Modifier) {
+	switch {
+	case ch != 0 && mod == 0:
+		in.insert(ch)
+	case key == KeySpace:
+		in.insert(' ')
+	case key == KeyBackspace || key == KeyBackspace2:
+		if in.pos > 0 {
+			in.delete(in.prevBound(in.pos), in.pos)
+		}
+	case key == KeyDelete || key == KeyCtrlD:
+		if in.pos < len(in.text) {
+			in.delete(in.pos, in.nextBound(in.pos))
+		}
+	case key == KeyArrowLeft || key == KeyCtrlB:
+		in.moveTo(in.prevBound(in.pos))
+	case key == KeyArrowRight || key == KeyCtrlF:
+		in.moveTo(in.nextBound(in.pos))
+	case key == KeyHome || key == KeyCtrlA:
+		in.moveTo(0)
+	case key == KeyEnd || key == KeyCtrlE:
+		in.moveTo(len(in.text))
+	case key == KeyCtrlU:
+		in.delete(0, in.pos)
+	case key == KeyCtrlK:
+		in.delete(in.pos, len(in.text))
+	case key == KeyCtrlW:
+		start := in.pos
+		for start > 0 && unicode.IsSpace(in.text[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(in.text[start-1]) {
+			start--
+		}
+		in.delete(start, in.pos)
+	}
+}
+
+// insert inserts a rune at the cursor position, unless the text already has
+// MaxLength characters.
+func (in *Input) insert(ch rune) {
+	if in.MaxLength > 0 && len(in.text) >= in.MaxLength {
+		return
+	}
+	in.text = append(in.text, 0)
+	copy(in.text[in.pos+1:], in.text[in.pos:])
+	in.text[in.pos] = ch
+	in.pos++
+	in.changed()
+}
+
+// delete removes the runes of the text from start to end (excluded).
+func (in *Input) delete(start, end int) {
+	if start >= end {
+		return
+	}
+	in.text = append(in.text[:start], in.text[end:]...)
+	in.pos = start
+	in.changed()
+}
+
+// moveTo moves the cursor to a position of the text.
+func (in *Input) moveTo(pos int) {
+	in.pos = max(0, min(pos, len(in.text)))
+	in.render()
+}
+
+// prevBound returns the start of the grapheme cluster before pos.
+func (in *Input) prevBound(pos int) int {
+	bounds := graphemeBounds(in.text)
+	for i := len(bounds) - 1; i >= 0; i-- {
+		if bounds[i] < pos {
+			return bounds[i]
+		}
+	}
+	return 0
+}
+
+// nextBound returns the start of the grapheme cluster after pos.
+func (in *Input) nextBound(pos int) int {
+	for _, b := range graphemeBounds(in.text) {
+		if b > pos {
+			return b
+		}
+	}
+	return len(in.text)
+}
+
+// changed validates the text and displays it.
+func (in *Input) changed() {
+	in.err = nil
+	if in.Validate != nil {
+		in.err = in.Validate(string(in.text))
+	}
+	in.render()
+}
+
+func (in *Input) submit(g *Gui, v *View) error {
+	if in.Validate != nil {
+		if in.err = in.Validate(string(in.text)); in.err != nil {
+			in.render()
+			return nil
+		}
+	}
+	text := string(in.text)
+	if err := in.AddHistory(text); err != nil {
+		return err
+	}
+	in.text, in.pos, in.draft = nil, 0, ""
+	in.err = nil
+	in.render()
+	if in.OnSubmit != nil {
+		return in.OnSubmit(g, text)
+	}
+	return nil
+}
+
+func (in *Input) cancel(g *Gui, v *View) error {
+	if in.OnCancel != nil {
+		return in.OnCancel(g)
+	}
+	return nil
+}
+
+// historyUp replaces the text by the previous entry of the history. The text
+// being typed is kept to be restored by historyDown.
+func (in *Input) historyUp(g *Gui, v *View) error {
+	if in.historyPos <= 0 {
+		return nil
+	}
+	if in.historyPos >= len(in.history) {
+		in.draft = string(in.text)
+	}
+	in.historyPos--
+	in.SetText(in.history[in.historyPos])
+	return nil
+}
+
+// historyDown replaces the text by the next entry of the history, or by the
+// text being typed after the last entry.
+func (in *Input) historyDown(g *Gui, v *View) error {
+	if in.historyPos >= len(in.history) {
+		return nil
+	}
+	in.historyPos++
+	if in.historyPos == len(in.history) {
+		in.SetText(in.draft)
+	} else {
+		in.SetText(in.history[in.historyPos])
+	}
+	return nil
+}
+
+// render writes the visible part of the text, or the placeholder, and the
+// error in the view, and places the cursor.
+func (in *Input) render() {
+	v := in.v
+	if v == nil {
+		return
+	}
+	maxX, maxY := v.Size()
+
+	// scroll so that the cursor is visible, the last column is kept for the
+	// cursor at the end of the text
+	if in.pos < in.offset {
+		in.offset = in.pos
+	}
+	for in.offset < in.pos && lineWidth(runesToCells(in.text[in.offset:in.pos], 0, 0), defaultTabWidth) > maxX-1 {
+		in.offset = in.nextBound(in.offset)
+	}
+	in.offset = min(in.offset, len(in.text))
+
+	var line []cell
+	if len(in.text) == 0 && in.Placeholder != "" {
+		line = styledCells(in.Placeholder, in.v.roleStyleOr(RoleMuted, Style{Fg: AttrDim}))
+	} else {
+		line = runesToCells(in.text[in.offset:], 0, 0)
+	}
+	lines := [][]cell{line}
+
+	if in.errSubtitle {
+		v.Subtitle, in.errSubtitle = in.subtitle, false
+	}
+	if in.err != nil {
+		if maxY > 1 {
+			lines = append(lines, styledCells(in.err.Error(), in.v.roleStyleOr(RoleError, Style{Fg: ColorRed})))
+		} else {
+			in.subtitle, in.errSubtitle = v.Subtitle, true
+			v.Subtitle = in.err.Error()
+		}
+	}
+
+	v.lines = lines
+	v.version++
+	v.ox, v.oy = 0, 0
+	v.cx, v.cy = len(runesToCells(in.text[in.offset:in.pos], 0, 0)), 0
+	v.tainted = true
+}
+
+// styledCells returns the cells of a text with a style.
+func styledCells(text string, style Style) []cell {
+	cells := runesToCells([]rune(strings.Replace(text, "\n", " ", -1)), 0, 0)
+	for i := range cells {
+		cells[i] = styleCell(cells[i], style)
+	}
+	return cells
+}
@@ -0,0 +1,159 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestInput(width, height int) *Input {
+	in := NewInput("test")
+	in.v = newTestView(width, height)
+	in.v.Editor = in
+	return in
+}
+
+func TestInputScroll(t *testing.T) {
+	in := newTestInput(5, 1)
+	typeKeys(in, in.v, "abcdefgh")
+	if got := in.v.Buffer(); got != "efgh" {
+		t.Errorf("got %q, want %q", got, "efgh")
+	}
+	if x, _ := in.v.Cursor(); x != 4 {
+		t.Errorf("got cursor at %d, want 4", x)
+	}
+
+	typeKeys(in, in.v, "", editorKey{key: KeyHome}, editorKey{ch: 'x'})
+	if got := in.Text(); got != "xabcdefgh" {
+		t.Errorf("got text %q, want %q", got, "xabcdefgh")
+	}
+	if got := in.v.Buffer(); got != "xabcdefgh" {
+		t.Errorf("got %q, want %q", got, "xabcdefgh")
+	}
+	if x, _ := in.v.Cursor(); x != 1 {
+		t.Errorf("got cursor at %d, want 1", x)
+	}
+}
+
+func TestInputValidate(t *testing.T) {
+	in := newTestInput(10, 2)
+	in.Placeholder = "name"
+	in.MaxLength = 4
+	in.Validate = func(text string) error {
+		if text == "bad" {
+			return errors.New("invalid")
+		}
+		return nil
+	}
+	var submitted []string
+	in.OnSubmit = func(g *Gui, text string) error {
+		submitted = append(submitted, text)
+		return nil
+	}
+	in.render()
+	if got := in.v.Buffer(); got != "name" {
+		t.Errorf("got placeholder %q, want %q", got, "name")
+	}
+
+	typeKeys(in, in.v, "bad")
+	if got := in.v.Buffer(); got != "bad\ninvalid" {
+		t.Errorf("got %q, want the error under the text", got)
+	}
+	if err := in.submit(nil, in.v); err != nil || submitted != nil {
+		t.Errorf("submitted invalid text: %v, %q", err, submitted)
+	}
+
+	typeKeys(in, in.v, "dest")
+	if got := in.Text(); got != "badd" {
+		t.Errorf("got text %q, want it truncated to %q", got, "badd")
+	}
+	if err := in.submit(nil, in.v); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(submitted, []string{"badd"}) || in.Text() != "" {
+		t.Errorf("got submitted %q and text %q", submitted, in.Text())
+	}
+}
+
+func TestInputHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gocui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "history")
+	if err := ioutil.WriteFile(path, []byte("one\ntwo\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	in := newTestInput(10, 1)
+	if err := in.LoadHistory(path); err != nil {
+		t.Fatal(err)
+	}
+	typeKeys(in, in.v, "thr")
+	in.historyUp(nil, in.v)
+	in.historyUp(nil, in.v)
+	in.historyUp(nil, in.v)
+	if got := in.Text(); got != "one" {
+		t.Errorf("got %q, want %q", got, "one")
+	}
+	in.historyDown(nil, in.v)
+	in.historyDown(nil, in.v)
+	if got := in.Text(); got != "thr" {
+		t.Errorf("got %q, want the draft %q", got, "thr")
+	}
+
+	typeKeys(in, in.v, "ee")
+	if err := in.submit(nil, in.v); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "one\ntwo\nthree\n" {
+		t.Errorf("got history file %q", data)
+	}
+
+	// the file is truncated to MaxHistory
+	in.MaxHistory = 3
+	if err := in.AddHistory("four"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != "two\nthree\nfour\n" {
+		t.Errorf("got history file %q", data)
+	}
+}
+
+func TestInputSetView(t *testing.T) {
+	g := &Gui{}
+	in := NewInput("prompt")
+	if _, err := in.SetView(g, 0, 0, 20, 2); !errors.Is(err, ErrUnknownView) {
+		t.Fatalf("got error %v, want ErrUnknownView", err)
+	}
+	if _, err := in.SetView(g, 0, 0, 20, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// the view is set up again after it was deleted
+	if err := g.DeleteView("prompt"); err != nil {
+		t.Fatal(err)
+	}
+	v, err := in.SetView(g, 0, 0, 20, 2)
+	if !errors.Is(err, ErrUnknownView) {
+		t.Fatalf("got error %v, want ErrUnknownView", err)
+	}
+	if in.v != v || !v.Editable || v.Editor != in {
+		t.Errorf("new view not set up")
+	}
+	if n := len(g.keybindings); n != 4 {
+		t.Errorf("got %d keybindings, want 4", n)
+	}
+}
@@ -119,81 +119,84 @@ func (kb *keybinding) matchView(v *View) bool {
 
 // translations for strings to keys
 var translate = map[string]Key{
-	"F1":             KeyF1,
-	"F2":             KeyF2,
-	"F3":             KeyF3,
-	"F4":             KeyF4,
-	"F5":             KeyF5,
-	"F6":             KeyF6,
-	"F7":             KeyF7,
-	"F8":             KeyF8,
-	"F9":             KeyF9,
-	"F10":            KeyF10,
-	"F11":            KeyF11,
-	"F12":            KeyF12,
-	"Insert":         KeyInsert,
-	"Delete":         KeyDelete,
-	"Home":           KeyHome,
-	"End":            KeyEnd,
-	"Pgup":           KeyPgup,
-	"Pgdn":           KeyPgdn,
-	"ArrowUp":        KeyArrowUp,
-	"ArrowDown":      KeyArrowDown,
-	"ArrowLeft":      KeyArrowLeft,
-	"ArrowRight":     KeyArrowRight,
-	"CtrlTilde":      KeyCtrlTilde,
-	"Ctrl2":          KeyCtrl2,
-	"CtrlSpace":      KeyCtrlSpace,
-	"CtrlA":          KeyCtrlA,
-	"CtrlB":          KeyCtrlB,
-	"CtrlC":          KeyCtrlC,
-	"CtrlD":          KeyCtrlD,
-	"CtrlE":          KeyCtrlE,
-	"CtrlF":          KeyCtrlF,
-	"CtrlG":          KeyCtrlG,
-	"Backspace":      KeyBackspace,
-	"CtrlH":          KeyCtrlH,
-	"Tab":            KeyTab,
-	"Backtab":        KeyBacktab,
-	"CtrlI":          KeyCtrlI,
-	"CtrlJ":          KeyCtrlJ,
-	"CtrlK":          KeyCtrlK,
-	"CtrlL":          KeyCtrlL,
-	"Enter":          KeyEnter,
-	"CtrlM":          KeyCtrlM,
-	"CtrlN":          KeyCtrlN,
-	"CtrlO":          KeyCtrlO,
-	"CtrlP":          KeyCtrlP,
-	"CtrlQ":          KeyCtrlQ,
-	"CtrlR":          KeyCtrlR,
-	"CtrlS":          KeyCtrlS,
-	"CtrlT":          KeyCtrlT,
-	"CtrlU":          KeyCtrlU,
-	"CtrlV":          KeyCtrlV,
-	"CtrlW":          KeyCtrlW,
-	"CtrlX":          KeyCtrlX,
-	"CtrlY":          KeyCtrlY,
-	"CtrlZ":          KeyCtrlZ,
-	"Esc":            KeyEsc,
-	"CtrlLsqBracket": KeyCtrlLsqBracket,
-	"Ctrl3":          KeyCtrl3,
-	"Ctrl4":          KeyCtrl4,
-	"CtrlBackslash":  KeyCtrlBackslash,
-	"Ctrl5":          KeyCtrl5,
-	"CtrlRsqBracket": KeyCtrlRsqBracket,
-	"Ctrl6":          KeyCtrl6,
-	"Ctrl7":          KeyCtrl7,
-	"CtrlSlash":      KeyCtrlSlash,
-	"CtrlUnderscore": KeyCtrlUnderscore,
-	"Space":          KeySpace,
-	"Backspace2":     KeyBackspace2,
-	"Ctrl8":          KeyCtrl8,
-	"Mouseleft":      MouseLeft,
-	"Mousemiddle":    MouseMiddle,
-	"Mouseright":     MouseRight,
-	"Mouserelease":   MouseRelease,
-	"MousewheelUp":   MouseWheelUp,
-	"MousewheelDown": MouseWheelDown,
+	"F1":              KeyF1,
+	"F2":              KeyF2,
+	"F3":              KeyF3,
+	"F4":              KeyF4,
+	"F5":              KeyF5,
+	"F6":              KeyF6,
+	"F7":              KeyF7,
+	"F8":              KeyF8,
+	"F9":              KeyF9,
+	"F10":             KeyF10,
+	"F11":             KeyF11,
+	"F12":             KeyF12,
+	"Insert":          KeyInsert,
+	"Delete":          KeyDelete,
+	"Home":            KeyHome,
+	"End":             KeyEnd,
+	"Pgup":            KeyPgup,
+	"Pgdn":            KeyPgdn,
+	"ArrowUp":         KeyArrowUp,
+	"ArrowDown":       KeyArrowDown,
+	"ArrowLeft":       KeyArrowLeft,
+	"ArrowRight":      KeyArrowRight,
+	"CtrlTilde":       KeyCtrlTilde,
+	"Ctrl2":           KeyCtrl2,
+	"CtrlSpace":       KeyCtrlSpace,
+	"CtrlA":           KeyCtrlA,
+	"CtrlB":           KeyCtrlB,
+	"CtrlC":           KeyCtrlC,
+	"CtrlD":           KeyCtrlD,
+	"CtrlE":           KeyCtrlE,
+	"CtrlF":           KeyCtrlF,
+	"CtrlG":           KeyCtrlG,
+	"Backspace":       KeyBackspace,
+	"CtrlH":           KeyCtrlH,
+	"Tab":             KeyTab,
+	"Backtab":         KeyBacktab,
+	"CtrlI":           KeyCtrlI,
+	"CtrlJ":           KeyCtrlJ,
+	"CtrlK":           KeyCtrlK,
+	"CtrlL":           KeyCtrlL,
+	"Enter":           KeyEnter,
+	"CtrlM":           KeyCtrlM,
+	"CtrlN":           KeyCtrlN,
+	"CtrlO":           KeyCtrlO,
+	"CtrlP":           KeyCtrlP,
+	"CtrlQ":           KeyCtrlQ,
+	"CtrlR":           KeyCtrlR,
+	"CtrlS":           KeyCtrlS,
+	"CtrlT":           KeyCtrlT,
+	"CtrlU":           KeyCtrlU,
+	"CtrlV":           KeyCtrlV,
+	"CtrlW":           KeyCtrlW,
+	"CtrlX":           KeyCtrlX,
+	"CtrlY":           KeyCtrlY,
+	"CtrlZ":           KeyCtrlZ,
+	"Esc":             KeyEsc,
+	"CtrlLsqBracket":  KeyCtrlLsqBracket,
+	"Ctrl3":           KeyCtrl3,
+	"Ctrl4":           KeyCtrl4,
+	"CtrlBackslash":   KeyCtrlBackslash,
+	"Ctrl5":           KeyCtrl5,
+	"CtrlRsqBracket":  KeyCtrlRsqBracket,
+	"Ctrl6":           KeyCtrl6,
+	"Ctrl7":           KeyCtrl7,
+	"CtrlSlash":       KeyCtrlSlash,
+	"CtrlUnderscore":  KeyCtrlUnderscore,
+	"Space":           KeySpace,
+	"Backspace2":      KeyBackspace2,
+	"Ctrl8":           KeyCtrl8,
+	"Mouseleft":       MouseLeft,
+	"Mousemiddle":     MouseMiddle,
+	"Mouseright":      MouseRight,
+	"Mouserelease":    MouseRelease,
+	"MousewheelUp":    MouseWheelUp,
+	"MousewheelDown":  MouseWheelDown,
+	"Mousedrag":       MouseDrag,
+	"Mousemove":       MouseMove,
+	"Mouseleftdouble": MouseLeftDouble,
 }
 
 // Special keys.
@@ -274,6 +277,9 @@ const (
 	MouseWheelDown    = Key(tcell.KeyF58)
 	MouseWheelLeft    = Key(tcell.KeyF57)
 	MouseWheelRight   = Key(tcell.KeyF56)
+	MouseDrag         = Key(tcell.KeyF55) // a button is held down while the mouse moves
+	MouseMove         = Key(tcell.KeyF54) // the mouse moves without a button, see Gui.MouseMotion
+	MouseLeftDouble   = Key(tcell.KeyF53) // falls back to MouseLeft when not bound
 	KeyCtrl2          = Key(tcell.KeyNUL) // termbox defines theses
 	KeyCtrl3          = Key(tcell.KeyEscape)
 	KeyCtrl4          = Key(tcell.KeyCtrlBackslash)
@@ -0,0 +1,215 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+// frameDrag describes a view being moved or resized with the mouse.
+type frameDrag struct {
+	v *View
+
+	// edges holds the edges (TOP, BOTTOM, LEFT, RIGHT) following the
+	// pointer. All of them are set when the view is moved.
+	edges byte
+
+	// position of the pointer and of the view when the drag started
+	startX, startY int
+	x0, y0, x1, y1 int
+}
+
+// scrollbarDrag describes a scrollbar thumb being dragged with the mouse.
+type scrollbarDrag struct {
+	v        *View
+	vertical bool
+
+	// grab is the distance between the pointer and the start of the thumb
+	grab int
+}
+
+// minimum size of a view resized with the mouse, frame included
+const (
+	minDragWidth  = 2
+	minDragHeight = 1
+)
+
+// viewFrameAt returns the Draggable or Resizable view whose frame is at the
+// given position, along with the edges that must follow the pointer.
+func (g *Gui) viewFrameAt(x, y int) (*View, byte) {
+	for i := len(g.views); i > 0; i-- {
+		v := g.views[i-1]
+		if !v.Visible {
+			continue
+		}
+		if x > v.x0 && x < v.x1 && y > v.y0 && y < v.y1 {
+			// the inner area of a view hides the frames beneath it
+			return nil, 0
+		}
+		if !v.Frame || !(v.Draggable || v.Resizable) {
+			continue
+		}
+		if x < v.x0 || x > v.x1 || y < v.y0 || y > v.y1 {
+			continue
+		}
+
+		var edges byte
+		if x == v.x0 {
+			edges |= LEFT
+		}
+		if x == v.x1 {
+			edges |= RIGHT
+		}
+		if y == v.y0 {
+			edges |= TOP
+		}
+		if y == v.y1 {
+			edges |= BOTTOM
+		}
+
+		if edges == TOP && v.Draggable {
+			// the title bar
+			return v, TOP | BOTTOM | LEFT | RIGHT
+		}
+		if v.Resizable {
+			return v, edges
+		}
+		if edges&TOP != 0 && edges&BOTTOM == 0 {
+			// top corners of a view that can only be moved
+			return v, TOP | BOTTOM | LEFT | RIGHT
+		}
+	}
+	return nil, 0
+}
+
+// startFrameDrag starts moving or resizing the view whose frame is at the
+// given position. It returns false if there is no such view.
+func (g *Gui) startFrameDrag(x, y int) bool {
+	v, edges := g.viewFrameAt(x, y)
+	if v == nil {
+		return false
+	}
+	g.frameDrag = &frameDrag{
+		v:      v,
+		edges:  edges,
+		startX: x,
+		startY: y,
+		x0:     v.x0,
+		y0:     v.y0,
+		x1:     v.x1,
+		y1:     v.y1,
+	}
+	return true
+}
+
+// updateFrameDrag moves the dragged edges of the view to follow the pointer.
+func (g *Gui) updateFrameDrag(x, y int) {
+	fd := g.frameDrag
+	dx, dy := x-fd.startX, y-fd.startY
+	x0, y0, x1, y1 := fd.x0, fd.y0, fd.x1, fd.y1
+
+	if fd.edges == TOP|BOTTOM|LEFT|RIGHT {
+		x0, y0, x1, y1 = x0+dx, y0+dy, x1+dx, y1+dy
+	} else {
+		if fd.edges&LEFT != 0 {
+			x0 = min(x0+dx, x1-minDragWidth)
+		}
+		if fd.edges&RIGHT != 0 {
+			x1 = max(x1+dx, x0+minDragWidth)
+		}
+		if fd.edges&TOP != 0 {
+			y0 = min(y0+dy, y1-minDragHeight)
+		}
+		if fd.edges&BOTTOM != 0 {
+			y1 = max(y1+dy, y0+minDragHeight)
+		}
+	}
+
+	v := fd.v
+	v.x0, v.y0, v.x1, v.y1 = x0, y0, x1, y1
+	v.rectOverride = true
+	v.tainted = true
+}
+
+// endFrameDrag finishes the current move or resize and reports the final
+// dimensions of the view to OnViewRectChanged.
+func (g *Gui) endFrameDrag() error {
+	fd := g.frameDrag
+	g.frameDrag = nil
+	v := fd.v
+	if g.OnViewRectChanged == nil || !v.rectOverride {
+		return nil
+	}
+	if v.x0 == fd.x0 && v.y0 == fd.y0 && v.x1 == fd.x1 && v.y1 == fd.y1 {
+		return nil
+	}
+	return g.OnViewRectChanged(v, v.x0, v.y0, v.x1, v.y1)
+}
+
+// viewScrollbarAt returns the view whose scrollbar is at the given position,
+// the direction of the scrollbar and the position relative to its track.
+func (g *Gui) viewScrollbarAt(x, y int) (v *View, vertical bool, offset int) {
+	for i := len(g.views); i > 0; i-- {
+		v := g.views[i-1]
+		if !v.Visible {
+			continue
+		}
+		if x > v.x0 && x < v.x1 && y > v.y0 && y < v.y1 {
+			return nil, false, 0
+		}
+		if !v.Frame {
+			continue
+		}
+		if v.VerticalScrollbar && x == v.x1 && y > v.y0 && y < v.y1 {
+			if _, _, ok := v.scrollbarThumb(true); ok {
+				return v, true, y - v.y0 - 1
+			}
+		}
+		if v.HorizontalScrollbar && y == v.y1 && x > v.x0+v.gutterWidth() && x < v.x1 {
+			if _, _, ok := v.scrollbarThumb(false); ok {
+				return v, false, x - v.x0 - 1 - v.gutterWidth()
+			}
+		}
+	}
+	return nil, false, 0
+}
+
+// startScrollbarDrag starts dragging the scrollbar thumb at the given
+// position. Clicking the track outside of the thumb centers the thumb on the
+// pointer first. It returns false if there is no scrollbar there.
+func (g *Gui) startScrollbarDrag(x, y int) bool {
+	v, vertical, offset := g.viewScrollbarAt(x, y)
+	if v == nil {
+		return false
+	}
+	pos, size, _ := v.scrollbarThumb(vertical)
+	grab := offset - pos
+	if grab < 0 || grab >= size {
+		grab = size / 2
+		v.scrollToThumb(vertical, offset-grab)
+	}
+	g.scrollDrag = &scrollbarDrag{v: v, vertical: vertical, grab: grab}
+	return true
+}
+
+// updateScrollbarDrag scrolls the view so that the thumb follows the pointer.
+func (g *Gui) updateScrollbarDrag(x, y int) {
+	sd := g.scrollDrag
+	offset := x - sd.v.x0 - 1 - sd.v.gutterWidth()
+	if sd.vertical {
+		offset = y - sd.v.y0 - 1
+	}
+	sd.v.scrollToThumb(sd.vertical, offset-sd.grab)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
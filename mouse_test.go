@@ -0,0 +1,192 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestCountClick(t *testing.T) {
+	start := time.Now()
+	interval := 300 * time.Millisecond
+	type click struct {
+		button tcell.ButtonMask
+		x, y   int
+		after  time.Duration
+	}
+	tests := []struct {
+		name   string
+		clicks []click
+		want   []int
+	}{
+		{"double click", []click{{tcell.Button1, 1, 1, 0}, {tcell.Button1, 1, 1, 100 * time.Millisecond}}, []int{1, 2}},
+		{"triple click", []click{{tcell.Button1, 1, 1, 0}, {tcell.Button1, 1, 1, 300 * time.Millisecond}, {tcell.Button1, 1, 1, 600 * time.Millisecond}}, []int{1, 2, 3}},
+		{"timeout", []click{{tcell.Button1, 1, 1, 0}, {tcell.Button1, 1, 1, 301 * time.Millisecond}}, []int{1, 1}},
+		{"moved", []click{{tcell.Button1, 1, 1, 0}, {tcell.Button1, 2, 1, 0}, {tcell.Button1, 2, 2, 0}}, []int{1, 1, 1}},
+		{"other button", []click{{tcell.Button1, 1, 1, 0}, {tcell.Button2, 1, 1, 0}, {tcell.Button2, 1, 1, 0}}, []int{1, 1, 2}},
+	}
+	for _, tt := range tests {
+		var ms mouseState
+		for i, c := range tt.clicks {
+			if got := ms.countClick(c.button, c.x, c.y, start.Add(c.after), interval); got != tt.want[i] {
+				t.Errorf("%s: click %d: got %d clicks, want %d", tt.name, i, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestScroll(t *testing.T) {
+	g := &Gui{}
+	v := g.newView("test", 0, 0, 5, 4, OutputNormal)
+	for i := 0; i < 10; i++ {
+		if i > 0 {
+			fmt.Fprintln(v)
+		}
+		fmt.Fprintf(v, "line %d", i)
+	}
+
+	tests := []struct {
+		name   string
+		scroll func()
+		ox, oy int
+		paused bool
+	}{
+		{"down", func() { v.ScrollDown(2) }, 0, 2, false},
+		{"down past the end", func() { v.ScrollDown(100) }, 0, 7, false},
+		{"up past the start", func() { v.ScrollUp(100) }, 0, 0, false},
+		{"right", func() { v.ScrollRight(1) }, 1, 0, false},
+		{"right past the end", func() { v.ScrollRight(10) }, 2, 0, false},
+		{"left past the start", func() { v.ScrollLeft(5) }, 0, 0, false},
+		{"up pauses autoscroll", func() { v.Autoscroll = true; v.ScrollUp(1) }, 0, 6, true},
+		{"down to the bottom resumes autoscroll", func() { v.ScrollDown(1) }, 0, 7, false},
+		{"past the end with a final newline", func() { fmt.Fprintln(v); v.ScrollDown(5) }, 0, 7, false},
+		{"right with wrap", func() { v.Wrap = true; v.ScrollRight(1) }, 0, 7, false},
+	}
+	for _, tt := range tests {
+		tt.scroll()
+		if ox, oy := v.Origin(); ox != tt.ox || oy != tt.oy {
+			t.Errorf("%s: got origin (%d, %d), want (%d, %d)", tt.name, ox, oy, tt.ox, tt.oy)
+		}
+		if v.autoscrollPaused != tt.paused {
+			t.Errorf("%s: got autoscroll paused %v, want %v", tt.name, v.autoscrollPaused, tt.paused)
+		}
+	}
+}
+
+func TestViewFrameAt(t *testing.T) {
+	g := &Gui{}
+	v := g.newView("test", 2, 2, 10, 6, OutputNormal)
+	g.views = append(g.views, v)
+	all := byte(TOP | BOTTOM | LEFT | RIGHT)
+	tests := []struct {
+		name                 string
+		draggable, resizable bool
+		x, y                 int
+		edges                byte
+	}{
+		{"resize top left corner", false, true, 2, 2, TOP | LEFT},
+		{"resize bottom right corner", false, true, 10, 6, BOTTOM | RIGHT},
+		{"resize top edge", false, true, 6, 2, TOP},
+		{"resize left edge", false, true, 2, 4, LEFT},
+		{"resize inner area", false, true, 5, 4, 0},
+		{"resize outside", false, true, 11, 4, 0},
+		{"move title bar", true, false, 6, 2, all},
+		{"move top left corner", true, false, 2, 2, all},
+		{"move left edge", true, false, 2, 4, 0},
+		{"move bottom right corner", true, false, 10, 6, 0},
+		{"both title bar", true, true, 6, 2, all},
+		{"both top left corner", true, true, 2, 2, TOP | LEFT},
+		{"neither", false, false, 2, 2, 0},
+	}
+	for _, tt := range tests {
+		v.Draggable, v.Resizable = tt.draggable, tt.resizable
+		got, edges := g.viewFrameAt(tt.x, tt.y)
+		if tt.edges == 0 && got != nil || tt.edges != 0 && got != v || edges != tt.edges {
+			t.Errorf("%s: got view %v with edges %04b, want edges %04b", tt.name, got != nil, edges, tt.edges)
+		}
+	}
+
+	// the inner area of a view hides the frames beneath it
+	top := g.newView("top", 0, 0, 8, 8, OutputNormal)
+	g.views = append(g.views, top)
+	if got, _ := g.viewFrameAt(2, 2); got != nil {
+		t.Error("got the frame of a hidden view")
+	}
+}
+
+func TestUpdateFrameDrag(t *testing.T) {
+	tests := []struct {
+		name           string
+		fromX, fromY   int
+		toX, toY       int
+		x0, y0, x1, y1 int
+	}{
+		{"move", 6, 2, 7, 4, 3, 4, 11, 8},
+		{"grow", 10, 6, 12, 9, 2, 2, 12, 9},
+		{"shrink to the minimum from the bottom right", 10, 6, 0, 0, 2, 2, 2 + minDragWidth, 2 + minDragHeight},
+		{"shrink to the minimum from the top left", 2, 2, 20, 20, 10 - minDragWidth, 6 - minDragHeight, 10, 6},
+		{"resize right edge", 10, 4, 8, 0, 2, 2, 8, 6},
+	}
+	for _, tt := range tests {
+		g := &Gui{}
+		v := g.newView("test", 2, 2, 10, 6, OutputNormal)
+		v.Draggable, v.Resizable = true, true
+		g.views = append(g.views, v)
+		if !g.startFrameDrag(tt.fromX, tt.fromY) {
+			t.Errorf("%s: no frame at (%d, %d)", tt.name, tt.fromX, tt.fromY)
+			continue
+		}
+		g.updateFrameDrag(tt.toX, tt.toY)
+		if x0, y0, x1, y1 := v.Dimensions(); x0 != tt.x0 || y0 != tt.y0 || x1 != tt.x1 || y1 != tt.y1 {
+			t.Errorf("%s: got (%d, %d, %d, %d), want (%d, %d, %d, %d)", tt.name, x0, y0, x1, y1, tt.x0, tt.y0, tt.x1, tt.y1)
+		}
+	}
+}
+
+func TestScrollbarThumb(t *testing.T) {
+	g := &Gui{}
+	v := g.newView("test", 0, 0, 11, 11, OutputNormal)
+	tests := []struct {
+		content, origin int
+		pos, size       int
+		ok              bool
+	}{
+		{10, 0, 0, 0, false},
+		{20, 0, 0, 5, true},
+		{20, 10, 5, 5, true},
+		{11, 1, 1, 9, true},
+		{100, 45, 4, 1, true},
+		{100, 90, 9, 1, true},
+		{100, 200, 9, 1, true},
+	}
+	for _, tt := range tests {
+		v.contentHeight, v.oy = tt.content, tt.origin
+		pos, size, ok := v.scrollbarThumb(true)
+		if pos != tt.pos || size != tt.size || ok != tt.ok {
+			t.Errorf("content %d, origin %d: got thumb at %d of size %d (%v), want %d of size %d (%v)", tt.content, tt.origin, pos, size, ok, tt.pos, tt.size, tt.ok)
+		}
+	}
+
+	// dragging the thumb to a position scrolls to an origin showing the
+	// thumb at that position
+	for content := 11; content <= 100; content++ {
+		v.contentHeight = content
+		_, size, _ := v.scrollbarThumb(true)
+		for want := 0; want <= 10-size; want++ {
+			v.scrollToThumb(true, want)
+			if pos, _, _ := v.scrollbarThumb(true); pos != want {
+				t.Errorf("content %d: got thumb at %d after scrolling it to %d", content, pos, want)
+			}
+		}
+		v.scrollToThumb(true, 100)
+		if v.oy != content-10 {
+			t.Errorf("content %d: got origin %d after scrolling the thumb to the end, want %d", content, v.oy, content-10)
+		}
+	}
+}
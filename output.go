@@ -0,0 +1,63 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"os"
+	"strings"
+)
+
+// OutputModeEnv is the environment variable which overrides the output mode
+// selected by OutputAuto. Its value is one of "normal", "256", "216",
+// "grayscale", "true" or "none".
+const OutputModeEnv = "GOCUI_OUTPUT"
+
+// outputModeNames are the values accepted by OutputModeEnv.
+var outputModeNames = map[string]OutputMode{
+	"normal":    OutputNormal,
+	"8":         OutputNormal,
+	"16":        OutputNormal,
+	"256":       Output256,
+	"216":       Output216,
+	"grayscale": OutputGrayscale,
+	"greyscale": OutputGrayscale,
+	"true":      OutputTrue,
+	"truecolor": OutputTrue,
+	"24bit":     OutputTrue,
+	"none":      OutputNoColor,
+}
+
+// detectOutputMode returns the best output mode for the terminal, from the
+// environment and the number of colors reported by terminfo.
+func detectOutputMode(getenv func(string) string, colors int) OutputMode {
+	if mode, ok := outputModeNames[strings.ToLower(getenv(OutputModeEnv))]; ok {
+		return mode
+	}
+
+	// see https://no-color.org
+	if getenv("NO_COLOR") != "" {
+		return OutputNoColor
+	}
+
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return OutputTrue
+	}
+
+	term := getenv("TERM")
+	switch {
+	case colors >= 1<<24, strings.HasSuffix(term, "-direct"):
+		return OutputTrue
+	case colors >= 256, strings.Contains(term, "256color"):
+		return Output256
+	}
+	return OutputNormal
+}
+
+// autoOutputMode returns the output mode selected by OutputAuto for the
+// current screen.
+func autoOutputMode() OutputMode {
+	return detectOutputMode(os.Getenv, screen.Colors())
+}
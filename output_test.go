@@ -0,0 +1,46 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestDetectOutputMode(t *testing.T) {
+	tests := []struct {
+		env    map[string]string
+		colors int
+		want   OutputMode
+	}{
+		{map[string]string{"TERM": "xterm"}, 8, OutputNormal},
+		{map[string]string{"TERM": "xterm-256color"}, 8, Output256},
+		{map[string]string{"TERM": "xterm"}, 256, Output256},
+		{map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"}, 256, OutputTrue},
+		{map[string]string{"TERM": "xterm-direct"}, 8, OutputTrue},
+		{map[string]string{"TERM": "xterm"}, 1 << 24, OutputTrue},
+		{map[string]string{"COLORTERM": "truecolor", "NO_COLOR": "1"}, 1 << 24, OutputNoColor},
+		{map[string]string{"TERM": "xterm", "GOCUI_OUTPUT": "none"}, 8, OutputNoColor},
+		{map[string]string{"COLORTERM": "truecolor", "GOCUI_OUTPUT": "256"}, 1 << 24, Output256},
+		{map[string]string{"TERM": "xterm", "GOCUI_OUTPUT": "True"}, 8, OutputTrue},
+		{map[string]string{"TERM": "xterm", "GOCUI_OUTPUT": "bogus"}, 8, OutputNormal},
+	}
+
+	for _, test := range tests {
+		getenv := func(key string) string { return test.env[key] }
+		if got := detectOutputMode(getenv, test.colors); got != test.want {
+			t.Errorf("%v with %d colors: got %v, want %v", test.env, test.colors, got, test.want)
+		}
+	}
+}
+
+func TestOutputNoColor(t *testing.T) {
+	st := getTcellStyle(ColorRed|AttrBold, NewRGBColor(10, 20, 30), OutputNoColor)
+	fg, bg, attrs := st.Decompose()
+	if fg != tcell.ColorDefault || bg != tcell.ColorDefault || attrs&tcell.AttrBold == 0 {
+		t.Errorf("got fg %v, bg %v and attributes %v, want the default colors and bold", fg, bg, attrs)
+	}
+}
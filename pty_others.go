@@ -0,0 +1,22 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !linux
+
+package gocui
+
+import (
+	"os"
+	"os/exec"
+)
+
+// startPty is not supported on this platform.
+func startPty(cmd *exec.Cmd, width, height int) (*os.File, error) {
+	return nil, ErrTerminalUnsupported
+}
+
+// resizePty is not supported on this platform.
+func resizePty(pty *os.File, width, height int) error {
+	return ErrTerminalUnsupported
+}
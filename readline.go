@@ -0,0 +1,243 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "unicode"
+
+// killRingSize is the maximum number of entries of the kill ring of a
+// ReadlineEditor.
+const killRingSize = 30
+
+// ReadlineEditor is an Editor with the key bindings of GNU Readline and
+// Emacs. Besides the keys of DefaultEditor, it supports:
+//
+//	Ctrl+A, Home        start of the line
+//	Ctrl+E, End         end of the line
+//	Ctrl+B, Ctrl+F      previous and next character
+//	Ctrl+P, Ctrl+N      previous and next line
+//	Alt+B, Alt+F        previous and next word
+//	Alt+<, Alt+>        start and end of the buffer
+//	PageUp, PageDown    previous and next page
+//	Ctrl+D              delete the next character
+//	Ctrl+K, Ctrl+U      kill to the end and to the start of the line
+//	Alt+D, Ctrl+W       kill the next word and the previous blank separated word
+//	Alt+Backspace       kill the previous word
+//	Ctrl+Y, Alt+Y       yank the last killed text, replace it by the previous kill
+//	Ctrl+T              transpose the characters around the cursor
+//	Ctrl+_, Ctrl+Z      undo
+//	Alt+_               redo
+//
+// Consecutive kills are appended to the same entry of the kill ring, which is
+// shared by the views using the editor.
+type ReadlineEditor struct {
+	killRing []string
+
+	// state of the last command, used to append consecutive kills and to
+	// rotate the kill ring with Alt+Y after a yank
+	lastView  *View
+	lastKill  bool
+	lastYank  bool
+	yankIndex int
+}
+
+// NewReadlineEditor returns a ReadlineEditor with an empty kill ring.
+func NewReadlineEditor() *ReadlineEditor {
+	return &ReadlineEditor{}
+}
+
+// Edit handles a key press in an editable view. It implements the Editor
+// interface.
+func (e *ReadlineEditor) Edit(v *View, key Key, ch rune, mod Modifier) {
+	if e.lastView != v {
+		e.lastKill, e.lastYank = false, false
+		e.lastView = v
+	}
+	kill, yank := e.lastKill, e.lastYank
+	e.lastKill, e.lastYank = false, false
+
+	if mod&ModAlt != 0 {
+		e.editAlt(v, key, ch, kill, yank)
+		return
+	}
+
+	switch key {
+	case KeyCtrlA, KeyHome:
+		v.moveCursorTo(bufferPos{0, v.cy})
+	case KeyCtrlE, KeyEnd:
+		v.moveCursorTo(bufferPos{v.lineLen(v.cy), v.cy})
+	case KeyCtrlB:
+		v.MoveCursor(-1, 0)
+	case KeyCtrlF:
+		v.MoveCursor(1, 0)
+	case KeyCtrlP:
+		v.MoveCursor(0, -1)
+	case KeyCtrlN:
+		v.MoveCursor(0, 1)
+	case KeyPgup:
+		_, maxY := v.Size()
+		v.MoveCursor(0, -maxY)
+	case KeyPgdn:
+		_, maxY := v.Size()
+		v.MoveCursor(0, maxY)
+	case KeyCtrlD:
+		v.EditDelete(false)
+	case KeyCtrlK:
+		end := bufferPos{v.lineLen(v.cy), v.cy}
+		if v.cx >= end.x {
+			// kill the line break
+			end, _ = v.nextPos(end)
+		}
+		e.kill(v, v.cursorPos(), end, false, kill)
+	case KeyCtrlU:
+		e.kill(v, bufferPos{0, v.cy}, v.cursorPos(), true, kill)
+	case KeyCtrlW:
+		start := v.cursorPos()
+		start = v.skipBackward(start, unicode.IsSpace)
+		start = v.skipBackward(start, func(r rune) bool { return !unicode.IsSpace(r) })
+		e.kill(v, start, v.cursorPos(), true, kill)
+	case KeyCtrlY:
+		e.yank(v, 0)
+	case KeyCtrlT:
+		e.transpose(v)
+	case KeyCtrlUnderscore, KeyCtrlZ:
+		v.Undo()
+	default:
+		simpleEditor(v, key, ch, mod)
+	}
+}
+
+// editAlt handles the keys pressed with Alt.
+func (e *ReadlineEditor) editAlt(v *View, key Key, ch rune, kill, yank bool) {
+	switch {
+	case ch == 'b':
+		v.moveCursorTo(v.wordStart(v.cursorPos()))
+	case ch == 'f':
+		v.moveCursorTo(v.wordEnd(v.cursorPos()))
+	case ch == 'd':
+		e.kill(v, v.cursorPos(), v.wordEnd(v.cursorPos()), false, kill)
+	case key == KeyBackspace || key == KeyBackspace2:
+		e.kill(v, v.wordStart(v.cursorPos()), v.cursorPos(), true, kill)
+	case ch == 'y':
+		if yank && len(e.killRing) > 1 {
+			v.Undo()
+			e.yank(v, (e.yankIndex+1)%len(e.killRing))
+		}
+	case ch == '_':
+		v.Redo()
+	case ch == '<':
+		v.moveCursorTo(bufferPos{})
+	case ch == '>':
+		last := len(v.lines) - 1
+		if last >= 0 {
+			v.moveCursorTo(bufferPos{len(v.lines[last]), last})
+		}
+	}
+}
+
+// kill deletes the text between start and end and saves it in the kill
+// ring. If the last command was a kill, the text is added to the last entry,
+// before it when killing backward.
+func (e *ReadlineEditor) kill(v *View, start, end bufferPos, backward, appendKill bool) {
+	if !start.before(end) {
+		e.lastKill = appendKill
+		return
+	}
+	text := v.textRange(start, end)
+	v.edit(editOther, start.y, end.y, func() {
+		v.deleteRange(start, end)
+	})
+
+	switch {
+	case appendKill && len(e.killRing) > 0 && backward:
+		e.killRing[0] = text + e.killRing[0]
+	case appendKill && len(e.killRing) > 0:
+		e.killRing[0] += text
+	default:
+		e.killRing = append([]string{text}, e.killRing...)
+		if len(e.killRing) > killRingSize {
+			e.killRing = e.killRing[:killRingSize]
+		}
+	}
+	e.lastKill = true
+}
+
+// yank inserts the i-th entry of the kill ring at the cursor position.
+func (e *ReadlineEditor) yank(v *View, i int) {
+	if i >= len(e.killRing) {
+		return
+	}
+	v.edit(editOther, v.cy, v.cy, func() {
+		v.insertText(e.killRing[i])
+	})
+	e.yankIndex = i
+	e.lastYank = true
+}
+
+// transpose swaps the characters before and at the cursor, or the two last
+// characters at the end of the line, and moves the cursor forward.
+func (e *ReadlineEditor) transpose(v *View) {
+	if v.cy >= len(v.lines) {
+		return
+	}
+	x := v.cx
+	if x >= len(v.lines[v.cy]) {
+		x = len(v.lines[v.cy]) - 1
+	}
+	if x < 1 {
+		return
+	}
+	v.edit(editOther, v.cy, v.cy, func() {
+		line := append([]cell(nil), v.lines[v.cy]...)
+		line[x-1], line[x] = line[x], line[x-1]
+		v.lines[v.cy] = line
+		v.tainted = true
+		v.moveCursorTo(bufferPos{x + 1, v.cy})
+	})
+}
+
+// lineLen returns the length of the line y of the buffer, 0 if it doesn't
+// exist.
+func (v *View) lineLen(y int) int {
+	if y < 0 || y >= len(v.lines) {
+		return 0
+	}
+	return len(v.lines[y])
+}
+
+// wordStart returns the start of the word before p.
+func (v *View) wordStart(p bufferPos) bufferPos {
+	p = v.skipBackward(p, func(r rune) bool { return !isWordChar(r) })
+	return v.skipBackward(p, isWordChar)
+}
+
+// wordEnd returns the end of the word after p.
+func (v *View) wordEnd(p bufferPos) bufferPos {
+	p = v.skipForward(p, func(r rune) bool { return !isWordChar(r) })
+	return v.skipForward(p, isWordChar)
+}
+
+// skipForward returns the first position from p whose rune doesn't satisfy
+// f.
+func (v *View) skipForward(p bufferPos, f func(rune) bool) bufferPos {
+	for {
+		ch := v.charAt(p)
+		if ch == 0 || !f(ch) {
+			return p
+		}
+		p, _ = v.nextPos(p)
+	}
+}
+
+// skipBackward returns the first position before p, going backward, whose
+// preceding rune doesn't satisfy f.
+func (v *View) skipBackward(p bufferPos, f func(rune) bool) bufferPos {
+	for {
+		prev, ok := v.prevPos(p)
+		if !ok || !f(v.charAt(prev)) {
+			return p
+		}
+		p = prev
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"regexp"
+)
+
+// SearchOptions configures how View.Search matches the pattern.
+type SearchOptions struct {
+	// IgnoreCase makes the search case-insensitive.
+	IgnoreCase bool
+
+	// Regexp makes the pattern a regular expression, with the syntax of the
+	// regexp package, instead of plain text.
+	Regexp bool
+}
+
+// viewSearch is the active search of a view.
+type viewSearch struct {
+	re      *regexp.Regexp
+	matches []searchMatch
+	current int // index in matches, -1 if no match is selected
+
+	// version is the version of the buffer of the matches, see
+	// View.version, and searched is false until they are found
+	version  uint64
+	searched bool
+}
+
+// searchMatch is the position of a match in the buffer of a view: the line,
+// the index of its first cell and its number of cells.
+type searchMatch struct {
+	y, x, n int
+}
+
+// dropLines removes the matches of the first n lines of the buffer, which
+// were dropped, and moves the other ones up.
+func (s *viewSearch) dropLines(n int) {
+	d := 0
+	for d < len(s.matches) && s.matches[d].y < n {
+		d++
+	}
+	// the matches are sorted by line
+	s.matches = s.matches[d:]
+	for i := range s.matches {
+		s.matches[i].y -= n
+	}
+	if s.current >= 0 {
+		s.current -= d
+		if s.current < 0 {
+			s.current = -1
+		}
+	}
+}
+
+// before reports whether the match starts before the position x, y.
+func (m searchMatch) before(x, y int) bool {
+	return m.y < y || m.y == y && m.x < x
+}
+
+// Search highlights all the matches of pattern in the view, selects the first
+// one which is at or below the top of the view and scrolls it into view. The
+// matches are updated when the content of the view changes. An empty pattern
+// clears the search.
+func (v *View) Search(pattern string, opts SearchOptions) error {
+	if pattern == "" {
+		v.ClearSearch()
+		return nil
+	}
+	if !opts.Regexp {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	v.search = &viewSearch{re: re, current: -1}
+	v.updateMatches()
+	v.tainted = true
+
+	top := v.bufferLineAt(v.oy)
+	for i, m := range v.search.matches {
+		if m.y >= top {
+			v.selectMatch(i)
+			return nil
+		}
+	}
+	if len(v.search.matches) > 0 {
+		v.selectMatch(0)
+	}
+	return nil
+}
+
+// ClearSearch removes the highlighting of the matches of the search.
+func (v *View) ClearSearch() {
+	v.search = nil
+	v.tainted = true
+}
+
+// NextMatch selects the match following the current one, going back to the
+// first one after the last, and scrolls it into view. It returns false if
+// there is no match.
+func (v *View) NextMatch() bool {
+	return v.moveMatch(1)
+}
+
+// PrevMatch selects the match preceding the current one, going to the last
+// one before the first, and scrolls it into view. It returns false if there
+// is no match.
+func (v *View) PrevMatch() bool {
+	return v.moveMatch(-1)
+}
+
+// MatchCount returns the number of matches of the search.
+func (v *View) MatchCount() int {
+	if v.search == nil {
+		return 0
+	}
+	v.updateMatches()
+	return len(v.search.matches)
+}
+
+// MatchIndex returns the index, from 0, of the current match of the search,
+// or -1 if there is none.
+func (v *View) MatchIndex() int {
+	if v.search == nil {
+		return -1
+	}
+	v.updateMatches()
+	return v.search.current
+}
+
+func (v *View) moveMatch(delta int) bool {
+	if v.search == nil {
+		return false
+	}
+	v.updateMatches()
+	n := len(v.search.matches)
+	if n == 0 {
+		return false
+	}
+
+	i := v.search.current
+	switch {
+	case i >= 0:
+		i = (i + delta + n) % n
+	case delta > 0:
+		i = 0
+	default:
+		i = n - 1
+	}
+	v.selectMatch(i)
+	return true
+}
+
+// selectMatch makes the i-th match the current one and scrolls the view so
+// that it is visible.
+func (v *View) selectMatch(i int) {
+	v.search.current = i
+	v.tainted = true
+	m := v.search.matches[i]
+
+	maxX, maxY := v.Size()
+	x, y, _ := v.linesPosOnScreen(m.x, m.y)
+	if v.Autoscroll {
+		v.autoscrollPaused = true
+	}
+	if y < v.oy {
+		v.oy = y
+	} else if y >= v.oy+maxY {
+		v.oy = y - maxY + 1
+	}
+	if !v.Wrap {
+		end := v.BufferXToColumn(m.x+m.n, m.y)
+		if end > v.ox+maxX {
+			v.ox = end - maxX
+		}
+		if x < v.ox {
+			v.ox = x
+		}
+	}
+}
+
+// updateMatches searches the buffer again if it changed, keeping the current
+// match at the same position when it still matches.
+func (v *View) updateMatches() {
+	s := v.search
+	if s.searched && s.version == v.version {
+		return
+	}
+	s.version, s.searched = v.version, true
+	var current searchMatch
+	if s.current >= 0 {
+		current = s.matches[s.current]
+	}
+
+	s.matches = s.matches[:0]
+	for y, line := range v.lines {
+		// the indexes of the regexp are bytes, the ones of the matches are
+		// cells, which hold a grapheme cluster each
+		text, starts := lineText(line)
+		for _, loc := range s.re.FindAllStringIndex(text, -1) {
+			// matches starting or ending in a cluster include it
+			x, end := cellSpan(starts, loc[0], loc[1])
+			if end > x {
+				s.matches = append(s.matches, searchMatch{y: y, x: x, n: end - x})
+			}
+		}
+	}
+
+	if s.current < 0 {
+		return
+	}
+	s.current = -1
+	for i, m := range s.matches {
+		if !m.before(current.x, current.y) {
+			if m == current {
+				s.current = i
+			}
+			break
+		}
+	}
+}
+
+// highlightMatches returns the lines with the styles of the matches
+// applied. Only the lines with matches are copied.
+func (v *View) highlightMatches(buffer [][]cell) [][]cell {
+	lines := make([][]cell, len(buffer))
+	copy(lines, buffer)
+	copied := -1
+	for i, m := range v.search.matches {
+		style := v.MatchStyle
+		if i == v.search.current {
+			style = v.CurrentMatchStyle
+		}
+		// the matches are sorted by line
+		if m.y != copied {
+			lines[m.y] = append([]cell(nil), buffer[m.y]...)
+			copied = m.y
+		}
+		for x := m.x; x < m.x+m.n; x++ {
+			lines[m.y][x] = styleCell(lines[m.y][x], style)
+		}
+	}
+	return lines
+}
+
+// styleCell returns the cell with a style applied: the colors of the style
+// replace the ones of the cell, unless they are ColorDefault, and its text
+// attributes are added to the ones of the cell.
+func styleCell(c cell, style Style) cell {
+	if style.Fg&AttrColorBits != ColorDefault {
+		c.fgColor &^= AttrColorBits
+	}
+	if style.Bg&AttrColorBits != ColorDefault {
+		c.bgColor &^= AttrColorBits
+	}
+	c.fgColor |= style.Fg
+	c.bgColor |= style.Bg
+	return c
+}
+
+// bufferLineAt returns the index of the buffer line displayed at the given
+// line of the view, counted from the first line of the buffer.
+func (v *View) bufferLineAt(viewY int) int {
+	if !v.Wrap {
+		return viewY
+	}
+	for y, line := range v.lines {
+		for continuation := false; ; continuation = true {
+			if viewY == 0 {
+				return y
+			}
+			_, _, end := v.takeLine(&line, continuation)
+			if end {
+				break
+			}
+			viewY--
+		}
+		viewY--
+	}
+	return len(v.lines)
+}
@@ -0,0 +1,336 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"encoding/base64"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// bufferPos is a position in the buffer of a view: a line and the index of a
+// cell in this line.
+type bufferPos struct {
+	x, y int
+}
+
+// before reports whether p is before q.
+func (p bufferPos) before(q bufferPos) bool {
+	return p.y < q.y || p.y == q.y && p.x < q.x
+}
+
+// bufferRange is a range of the buffer of a view, end is excluded.
+type bufferRange struct {
+	start, end bufferPos
+}
+
+// union returns the smallest range containing r and o.
+func (r bufferRange) union(o bufferRange) bufferRange {
+	if o.start.before(r.start) {
+		r.start = o.start
+	}
+	if r.end.before(o.end) {
+		r.end = o.end
+	}
+	return r
+}
+
+// selection units, depending on the number of clicks
+const (
+	selectChars = iota + 1
+	selectWords
+	selectLines
+)
+
+// textSelect describes text being selected with the mouse.
+type textSelect struct {
+	v    *View
+	unit int
+
+	// anchor is the character, word or line where the selection started
+	anchor bufferRange
+
+	// moved is true once the pointer moved, a simple click doesn't select
+	// any character
+	moved bool
+}
+
+// startTextSelect starts selecting text if the position is in a Selectable
+// view. Clicking replaces the previous selection of the view.
+func (g *Gui) startTextSelect(x, y, clicks int) {
+	v, err := g.ViewByPosition(x, y)
+	if err != nil || !v.Selectable || x <= v.x0 || x >= v.x1 || y <= v.y0 || y >= v.y1 {
+		return
+	}
+	unit := selectChars
+	if clicks == 2 {
+		unit = selectWords
+	} else if clicks >= 3 {
+		unit = selectLines
+	}
+
+	pos := v.bufferPosAt(x-v.x0-1-v.gutterWidth(), y-v.y0-1)
+	ts := &textSelect{v: v, unit: unit, anchor: v.unitRange(pos, unit)}
+	g.textSelect = ts
+	v.selection = nil
+	if unit != selectChars {
+		v.selection = &ts.anchor
+	}
+	v.tainted = true
+}
+
+// updateTextSelect extends the selection to the position of the pointer.
+// The view is scrolled when the pointer is above or below it.
+func (g *Gui) updateTextSelect(x, y int) {
+	ts := g.textSelect
+	v := ts.v
+	maxX, maxY := v.Size()
+	vx, vy := x-v.x0-1-v.gutterWidth(), y-v.y0-1
+	if vy < 0 {
+		v.ScrollUp(1)
+		vy = 0
+	} else if vy >= maxY {
+		v.ScrollDown(1)
+		vy = maxY - 1
+	}
+	vx = max(0, min(vx, maxX))
+
+	sel := ts.anchor.union(v.unitRange(v.bufferPosAt(vx, vy), ts.unit))
+	ts.moved = true
+	v.selection = &sel
+	v.tainted = true
+}
+
+// endTextSelect finishes the selection. A simple click clears it.
+func (g *Gui) endTextSelect() {
+	ts := g.textSelect
+	g.textSelect = nil
+	if ts.unit == selectChars && !ts.moved {
+		ts.v.selection = nil
+	}
+}
+
+// unitRange returns the character, word or line at a position.
+func (v *View) unitRange(pos bufferPos, unit int) bufferRange {
+	if pos.y >= len(v.lines) {
+		return bufferRange{start: pos, end: pos}
+	}
+	line := v.lines[pos.y]
+	switch unit {
+	case selectWords:
+		if pos.x >= len(line) {
+			return bufferRange{start: pos, end: pos}
+		}
+		start, end := pos.x, pos.x+1
+		if isWordChar(line[pos.x].chr) {
+			for start > 0 && isWordChar(line[start-1].chr) {
+				start--
+			}
+			for end < len(line) && isWordChar(line[end].chr) {
+				end++
+			}
+		}
+		return bufferRange{start: bufferPos{start, pos.y}, end: bufferPos{end, pos.y}}
+	case selectLines:
+		return bufferRange{start: bufferPos{0, pos.y}, end: bufferPos{len(line), pos.y}}
+	}
+	end := pos
+	if end.x < len(line) {
+		end.x++
+	}
+	return bufferRange{start: pos, end: end}
+}
+
+// isWordChar reports whether r is part of the words selected by a double
+// click.
+func isWordChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// bufferPosAt returns the position in the buffer of the cell displayed at
+// the given position of the view. Positions after the end of a line are at
+// the end of the line.
+func (v *View) bufferPosAt(x, y int) bufferPos {
+	if len(v.lines) == 0 {
+		return bufferPos{}
+	}
+	row := v.oy + max(y, 0)
+
+	if !v.Wrap {
+		if row >= len(v.lines) {
+			row = len(v.lines) - 1
+			return bufferPos{len(v.lines[row]), row}
+		}
+		return bufferPos{cellIndexAt(v.lines[row], 0, v.ox+x, v.tabWidth()), row}
+	}
+
+	for y, line := range v.lines {
+		for continuation := false; ; continuation = true {
+			start := len(v.lines[y]) - len(line)
+			cells, _, end := v.takeLine(&line, continuation)
+			if row == 0 {
+				indent := 0
+				if continuation {
+					indent = v.wrapPrefixWidth()
+				}
+				return bufferPos{start + cellIndexAt(cells, indent, x, v.tabWidth()), y}
+			}
+			row--
+			if end {
+				break
+			}
+		}
+	}
+	last := len(v.lines) - 1
+	return bufferPos{len(v.lines[last]), last}
+}
+
+// cellIndexAt returns the index of the cell displayed at the column col of a
+// line drawn from the column start, with tab stops every tabWidth columns,
+// or the length of the line if the column is after its end.
+func cellIndexAt(line []cell, start, col, tabWidth int) int {
+	if col < start {
+		return 0
+	}
+	for i, c := range line {
+		start += cellWidthAt(c, start, tabWidth)
+		if col < start {
+			return i
+		}
+	}
+	return len(line)
+}
+
+// HasSelection reports whether some text of the view is selected.
+func (v *View) HasSelection() bool {
+	return v.selection != nil
+}
+
+// ClearSelection unselects the text selected with the mouse.
+func (v *View) ClearSelection() {
+	v.selection = nil
+	v.tainted = true
+}
+
+// SelectedText returns the text selected with the mouse, with the lines
+// separated by '\n'. It returns an empty string if there is no selection.
+func (v *View) SelectedText() string {
+	if v.selection == nil {
+		return ""
+	}
+	start, end := v.selection.start, v.selection.end
+	var lines []string
+	for y := start.y; y <= end.y && y < len(v.lines); y++ {
+		line := v.lines[y]
+		x0, x1 := 0, len(line)
+		if y == start.y {
+			x0 = min(start.x, len(line))
+		}
+		if y == end.y {
+			x1 = min(end.x, len(line))
+		}
+		if x1 < x0 {
+			x1 = x0
+		}
+		lines = append(lines, strings.Replace(lineType(line[x0:x1]).String(), "\x00", " ", -1))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CopySelection copies the text selected with the mouse to the clipboard,
+// see Gui.CopyToClipboard.
+func (v *View) CopySelection() error {
+	if v.selection == nil {
+		return nil
+	}
+	return v.gui.CopyToClipboard(v.SelectedText())
+}
+
+// highlightSelection returns the lines with the style of the selection
+// applied to the selected cells. Only the selected lines are copied.
+func (v *View) highlightSelection(lines [][]cell) [][]cell {
+	styled := make([][]cell, len(lines))
+	copy(styled, lines)
+	start, end := v.selection.start, v.selection.end
+	for y := start.y; y <= end.y && y < len(lines); y++ {
+		line := append([]cell(nil), lines[y]...)
+		x0, x1 := 0, len(line)
+		if y == start.y {
+			x0 = min(start.x, len(line))
+		}
+		if y == end.y {
+			x1 = min(end.x, len(line))
+		}
+		for x := x0; x < x1; x++ {
+			line[x] = styleCell(line[x], v.SelectionStyle)
+		}
+		styled[y] = line
+	}
+	return styled
+}
+
+// CopyToClipboard copies text to the system clipboard with the OSC 52 escape
+// sequence, which is supported by most terminal emulators, or with the
+// ClipboardWriter. The text is sent from the main loop after the next flush
+// of the screen, so that it doesn't interleave with the output of the GUI.
+// If LocalClipboard is set, the text is also kept for Clipboard. It can be
+// called from any goroutine.
+func (g *Gui) CopyToClipboard(text string) error {
+	g.clipboardMutex.Lock()
+	defer g.clipboardMutex.Unlock()
+	if g.LocalClipboard {
+		g.clipboard = text
+	}
+	g.clipboardQueue = append(g.clipboardQueue, text)
+	return nil
+}
+
+// flushClipboard sends the texts copied since the last flush to the
+// clipboard. Without ClipboardWriter, the escape sequence is written to
+// /dev/tty, it is dropped if there is none.
+func (g *Gui) flushClipboard() error {
+	g.clipboardMutex.Lock()
+	queue := g.clipboardQueue
+	g.clipboardQueue = nil
+	g.clipboardMutex.Unlock()
+	if len(queue) == 0 || g.outputMode == OutputSimulator {
+		return nil
+	}
+
+	for _, text := range queue {
+		if g.ClipboardWriter != nil {
+			if err := g.ClipboardWriter(text); err != nil {
+				return err
+			}
+			continue
+		}
+		tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+		if err != nil {
+			return nil
+		}
+		err = writeOSC52(tty, text)
+		tty.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clipboard returns the text copied last with CopyToClipboard when
+// LocalClipboard is set.
+func (g *Gui) Clipboard() string {
+	g.clipboardMutex.Lock()
+	defer g.clipboardMutex.Unlock()
+	return g.clipboard
+}
+
+// writeOSC52 writes the escape sequence setting the clipboard to text.
+func writeOSC52(w io.Writer, text string) error {
+	_, err := io.WriteString(w, "\x1b]52;c;"+base64.StdEncoding.EncodeToString([]byte(text))+"\x07")
+	return err
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"fmt"
+)
+
+// Style holds the foreground and background attributes used to write text.
+// Text attributes like AttrBold are combined with the colors, e.g.:
+//
+//	gocui.Style{Fg: gocui.ColorRed | gocui.AttrBold}
+//
+// The zero value uses the default colors of the view.
+type Style struct {
+	Fg, Bg Attribute
+}
+
+// Segment is a piece of text written with a given style.
+type Segment struct {
+	Style Style
+	Text  string
+}
+
+// Segments is a sequence of styled pieces of text, it can be built with Add
+// and written with View.WriteSegments:
+//
+//	line := gocui.Segments{}.
+//		Add(gocui.Style{Fg: gocui.ColorRed | gocui.AttrBold}, "ERROR ").
+//		Add(gocui.Style{}, msg)
+type Segments []Segment
+
+// Add returns the segments with a new segment appended.
+func (s Segments) Add(style Style, text string) Segments {
+	return append(s, Segment{Style: style, Text: text})
+}
+
+// WriteStyled writes text into the view's internal buffer, at the current
+// write position, using the given colors. Unlike Write, escape sequences are
+// not interpreted: every rune except '\n' and '\r' is written as is.
+func (v *View) WriteStyled(text string, fg, bg Attribute) {
+	v.tainted = true
+	v.writeMutex.Lock()
+	defer v.writeMutex.Unlock()
+	v.makeWriteable(v.wx, v.wy)
+	v.writeStyledRunes([]rune(text), fg, bg)
+	v.evictLines()
+}
+
+// WriteSegments writes each segment with its own style, see WriteStyled.
+func (v *View) WriteSegments(segments ...Segment) {
+	v.tainted = true
+	v.writeMutex.Lock()
+	defer v.writeMutex.Unlock()
+	v.makeWriteable(v.wx, v.wy)
+	for _, s := range segments {
+		v.writeStyledRunes([]rune(s.Text), s.Style.Fg, s.Style.Bg)
+	}
+	v.evictLines()
+}
+
+// Print formats its operands like fmt.Print and writes the result with the
+// given style, see WriteStyled.
+func (v *View) Print(style Style, a ...interface{}) {
+	v.WriteStyled(fmt.Sprint(a...), style.Fg, style.Bg)
+}
+
+// Println formats its operands like fmt.Println and writes the result with
+// the given style, see WriteStyled.
+func (v *View) Println(style Style, a ...interface{}) {
+	v.WriteStyled(fmt.Sprintln(a...), style.Fg, style.Bg)
+}
+
+// Printf formats according to a format specifier like fmt.Printf and writes
+// the result with the given style, see WriteStyled.
+func (v *View) Printf(style Style, format string, a ...interface{}) {
+	v.WriteStyled(fmt.Sprintf(format, a...), style.Fg, style.Bg)
+}
+
+// writeStyledRunes copies runes into the internal lines buffer using the
+// given colors. Caller must make sure that writing position is accessible.
+func (v *View) writeStyledRunes(p []rune, fg, bg Attribute) {
+	v.version++
+	v.ClearHistory()
+	cells := make([]cell, 0, len(p))
+	flush := func() {
+		if len(cells) > 0 {
+			v.writeCells(v.wx, v.wy, cells)
+			v.wx += len(cells)
+			cells = cells[:0]
+		}
+	}
+
+	for _, r := range p {
+		switch r {
+		case '\n':
+			flush()
+			v.wy++
+			if v.wy >= len(v.lines) {
+				v.lines = append(v.lines, nil)
+			}
+			v.wx = 0
+		case '\r':
+			flush()
+			v.wx = 0
+		default:
+			if n := len(cells); n > 0 && cells[n-1].extendedBy(r) {
+				cells[n-1] = cells[n-1].withRune(r)
+			} else if n > 0 || !v.joinCluster(v.wx, v.wy, r) {
+				cells = append(cells, cell{chr: r, fgColor: fg, bgColor: bg})
+			}
+		}
+	}
+	flush()
+}
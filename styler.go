@@ -0,0 +1,101 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"sort"
+	"strings"
+)
+
+// A StyleSpan applies a style to the bytes from Start to End (excluded) of a
+// line. The style is applied to the whole grapheme clusters including them.
+type StyleSpan struct {
+	Start, End int
+	Style      Style
+}
+
+// A Styler styles the lines of a view when they are drawn, e.g. to highlight
+// their syntax. It is only called for the lines visible in the view, with
+// the index y of the line in the buffer and its text. The styles of the
+// spans are applied on top of the colors of the text, the search matches and
+// the selection are drawn over them.
+type Styler interface {
+	StyleLine(v *View, y int, line string) []StyleSpan
+}
+
+// The StylerFunc type is an adapter to allow the use of ordinary functions
+// as Stylers. If f is a function with the appropriate signature,
+// StylerFunc(f) is a Styler object that calls f.
+type StylerFunc func(v *View, y int, line string) []StyleSpan
+
+// StyleLine calls f(v, y, line)
+func (f StylerFunc) StyleLine(v *View, y int, line string) []StyleSpan {
+	return f(v, y, line)
+}
+
+// lineText returns the text of a line and the byte offsets of its cells in
+// the text, followed by the length of the text.
+func lineText(line []cell) (string, []int) {
+	var b strings.Builder
+	starts := make([]int, 0, len(line)+1)
+	for _, c := range line {
+		starts = append(starts, b.Len())
+		b.WriteRune(c.chr)
+		for _, r := range c.comb {
+			b.WriteRune(r)
+		}
+	}
+	starts = append(starts, b.Len())
+	return b.String(), starts
+}
+
+// cellSpan returns the cells including the bytes from start to end
+// (excluded) of a text, given the byte offsets of its cells.
+func cellSpan(starts []int, start, end int) (x0, x1 int) {
+	x0 = sort.Search(len(starts), func(i int) bool { return starts[i] > start }) - 1
+	x1 = sort.SearchInts(starts, end)
+	return max(x0, 0), min(x1, len(starts)-1)
+}
+
+// styleLines returns the lines of the buffer with the styles of the Styler
+// applied to the lines visible in a view of the given height. Only the
+// styled lines are copied.
+func (v *View) styleLines(lines [][]cell, height int) [][]cell {
+	if len(lines) == 0 {
+		return lines
+	}
+	var first, last int
+	if v.Autoscroll && !v.autoscrollPaused {
+		// draw scrolls to the end of the buffer, the last lines filling
+		// the view and the line above are styled, without wrapping the
+		// whole buffer
+		first, last = len(lines)-1, len(lines)-1
+		for rows := v.lineRows(lines[first]); first > 0 && rows <= height+1; rows += v.lineRows(lines[first]) {
+			first--
+		}
+	} else {
+		first = v.bufferLineAt(v.oy)
+		last = min(v.bufferLineAt(v.oy+height-1), len(lines)-1)
+	}
+
+	styled := make([][]cell, len(lines))
+	copy(styled, lines)
+	for y := first; y <= last; y++ {
+		text, starts := lineText(lines[y])
+		spans := v.Styler.StyleLine(v, y, text)
+		if len(spans) == 0 {
+			continue
+		}
+		line := append([]cell(nil), lines[y]...)
+		for _, span := range spans {
+			x0, x1 := cellSpan(starts, span.Start, span.End)
+			for x := x0; x < x1; x++ {
+				line[x] = styleCell(line[x], span.Style)
+			}
+		}
+		styled[y] = line
+	}
+	return styled
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStyler(t *testing.T) {
+	v := newTestView(10, 2)
+	fmt.Fprint(v, "one\ntwo e\u0301te\u0301\nthree\nfour")
+	v.oy = 1
+
+	var styled []int
+	v.Styler = StylerFunc(func(v *View, y int, line string) []StyleSpan {
+		styled = append(styled, y)
+		i := strings.Index(line, "t")
+		return []StyleSpan{{Start: i, End: i + 1, Style: Style{Fg: ColorRed}}, {Start: len(line) - 1, End: len(line), Style: Style{Bg: ColorBlue}}}
+	})
+	lines := v.styleLines(v.lines, 2)
+
+	if !reflect.DeepEqual(styled, []int{1, 2}) {
+		t.Errorf("got styled lines %v, want only the visible ones", styled)
+	}
+	if !reflect.DeepEqual(lines[0], v.lines[0]) {
+		t.Errorf("line 0 styled")
+	}
+	// the span of the last byte includes the whole cluster
+	for _, c := range []struct{ x, y int }{{0, 1}, {6, 1}, {0, 2}, {4, 2}} {
+		if got, orig := lines[c.y][c.x], v.lines[c.y][c.x]; reflect.DeepEqual(got, orig) {
+			t.Errorf("cell (%d, %d) not styled", c.x, c.y)
+		}
+	}
+	if v.lines[1][0].fgColor != ColorDefault {
+		t.Errorf("buffer modified")
+	}
+
+	// an Autoscroll view shows the end of the buffer
+	v = newTestView(10, 2)
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(v, "line %d\n", i)
+	}
+	v.Autoscroll = true
+	v.Styler = StylerFunc(func(v *View, y int, line string) []StyleSpan {
+		styled = append(styled, y)
+		return nil
+	})
+	styled = nil
+	v.styleLines(v.lines, 2)
+	if !reflect.DeepEqual(styled, []int{7, 8, 9, 10}) {
+		t.Errorf("got styled lines %v, want the last ones", styled)
+	}
+}
+
+// spanTexts returns the text and role of the spans of a line.
+func spanTexts(v *View, line string, spans []StyleSpan) []string {
+	roles := map[Style]Role{}
+	for _, role := range []Role{RoleKeyword, RoleString, RoleNumber, RoleComment, RoleProperty} {
+		roles[tokenStyle(v, role)] = role
+	}
+	var texts []string
+	for _, s := range spans {
+		texts = append(texts, fmt.Sprintf("%s:%s", roles[s.Style], line[s.Start:s.End]))
+	}
+	return texts
+}
+
+func TestHighlighters(t *testing.T) {
+	v := newTestView(40, 5)
+	tests := []struct {
+		styler Styler
+		line   string
+		want   []string
+	}{
+		{JSONHighlighter, `{"name": "gocui", "stars": -1.5e3, "ok": true, "x": null}`, []string{
+			`property:"name"`, `string:"gocui"`, `property:"stars"`, `number:-1.5e3`, `property:"ok"`, `keyword:true`, `property:"x"`, `keyword:null`,
+		}},
+		{JSONHighlighter, `  "a \"quoted\" word",`, []string{`string:"a \"quoted\" word"`}},
+		{GoHighlighter, `func f() { return "x" + 'y' // 42`, []string{
+			`keyword:func`, `keyword:return`, `string:"x"`, `string:'y'`, `comment:// 42`,
+		}},
+		{GoHighlighter, `	x := 0x1F /* unterminated`, []string{`number:0x1F`, `comment:/* unterminated`}},
+	}
+	for _, tt := range tests {
+		spans := tt.styler.StyleLine(v, 0, tt.line)
+		if got := spanTexts(v, tt.line, spans); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
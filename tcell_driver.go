@@ -5,6 +5,8 @@
 package gocui
 
 import (
+	"time"
+
 	"github.com/gdamore/tcell/v2"
 )
 
@@ -45,10 +47,10 @@ func tcellInitSimulation() error {
 }
 
 // tcellSetCell sets the character cell at a given location to the given
-// content (rune) and attributes using provided OutputMode
-func tcellSetCell(x, y int, ch rune, fg, bg Attribute, omode OutputMode) {
+// content (rune and combining runes) and attributes using provided OutputMode
+func tcellSetCell(x, y int, ch rune, comb []rune, fg, bg Attribute, omode OutputMode) {
 	st := getTcellStyle(fg, bg, omode)
-	screen.SetContent(x, y, ch, nil, st)
+	screen.SetContent(x, y, ch, comb, st)
 }
 
 // getTcellStyle creates tcell.Style from Attributes
@@ -100,6 +102,8 @@ type gocuiEventType uint8
 // gocuiEvent represents events like a keys, mouse actions, or window resize.
 //  The 'Mod', 'Key' and 'Ch' fields are valid if 'Type' is 'eventKey'.
 //  The 'MouseX' and 'MouseY' fields are valid if 'Type' is 'eventMouse'.
+//  The 'StartX' and 'StartY' fields are valid if 'Key' is 'MouseDrag'.
+//  The 'Clicks' field is valid if 'Key' is a mouse button press.
 //  The 'Width' and 'Height' fields are valid if 'Type' is 'eventResize'.
 //  The 'Err' field is valid if 'Type' is 'eventError'.
 type gocuiEvent struct {
@@ -112,6 +116,9 @@ type gocuiEvent struct {
 	Err    error
 	MouseX int
 	MouseY int
+	StartX int
+	StartY int
+	Clicks int
 	N      int
 }
 
@@ -127,13 +134,43 @@ const (
 	eventTime
 )
 
-var (
-	lastMouseKey tcell.ButtonMask = tcell.ButtonNone
-	lastMouseMod tcell.ModMask    = tcell.ModNone
-)
+// mouseState keeps track of the pressed mouse button between tcell events,
+// so that drags and repeated clicks can be recognized. It is only accessed
+// from the goroutine polling events.
+type mouseState struct {
+	// key and mod of the button being held down
+	key tcell.ButtonMask
+	mod tcell.ModMask
+
+	// position where the button was pressed and last reported position
+	startX, startY int
+	lastX, lastY   int
+
+	// last click, used to count multi-clicks
+	clickKey   tcell.ButtonMask
+	clickX     int
+	clickY     int
+	clickTime  time.Time
+	clickCount int
+}
+
+// countClick returns how many times in a row the given button was clicked
+// at the same position, each click being at most interval after the
+// previous one.
+func (ms *mouseState) countClick(button tcell.ButtonMask, x, y int, when time.Time, interval time.Duration) int {
+	if ms.clickCount > 0 && button == ms.clickKey && x == ms.clickX && y == ms.clickY && when.Sub(ms.clickTime) <= interval {
+		ms.clickCount++
+	} else {
+		ms.clickCount = 1
+	}
+	ms.clickKey = button
+	ms.clickX, ms.clickY = x, y
+	ms.clickTime = when
+	return ms.clickCount
+}
 
 // pollEvent get tcell.Event and transform it into gocuiEvent
-func pollEvent() gocuiEvent {
+func (g *Gui) pollEvent() gocuiEvent {
 	tev := screen.PollEvent()
 	switch tev := tev.(type) {
 	case *tcell.EventInterrupt:
@@ -172,10 +209,12 @@ func pollEvent() gocuiEvent {
 			Mod:  Modifier(mod),
 		}
 	case *tcell.EventMouse:
+		ms := &g.mouseState
 		x, y := tev.Position()
 		button := tev.Buttons()
 		mouseKey := Key(0)
 		mouseMod := ModNone
+		clicks := 0
 		// process mouse wheel
 		if button&tcell.WheelUp != 0 {
 			mouseKey = MouseWheelUp
@@ -196,9 +235,10 @@ func pollEvent() gocuiEvent {
 
 		// process button events (not wheel events)
 		button &= tcell.ButtonMask(0xff)
-		if button != tcell.ButtonNone && lastMouseKey == tcell.ButtonNone {
-			lastMouseKey = button
-			lastMouseMod = tev.Modifiers()
+		if button != tcell.ButtonNone && ms.key == tcell.ButtonNone {
+			ms.key = button
+			ms.mod = tev.Modifiers()
+			ms.startX, ms.startY = x, y
 			switch tev.Buttons() {
 			case tcell.ButtonPrimary:
 				mouseKey = MouseLeft
@@ -207,23 +247,35 @@ func pollEvent() gocuiEvent {
 			case tcell.ButtonMiddle:
 				mouseKey = MouseMiddle
 			}
-			mouseMod = Modifier(lastMouseMod)
+			mouseMod = Modifier(ms.mod)
+			clicks = ms.countClick(button, x, y, tev.When(), g.DoubleClickInterval)
+		} else if button != tcell.ButtonNone && (x != ms.lastX || y != ms.lastY) {
+			// button is still held down and the pointer moved
+			mouseKey = MouseDrag
+			mouseMod = Modifier(ms.mod)
 		}
 
 		switch tev.Buttons() {
 		case tcell.ButtonNone:
-			if lastMouseKey != tcell.ButtonNone {
+			if ms.key != tcell.ButtonNone {
 				mouseKey = MouseRelease
-				mouseMod = Modifier(lastMouseMod)
-				lastMouseMod = tcell.ModNone
-				lastMouseKey = tcell.ButtonNone
+				mouseMod = Modifier(ms.mod)
+				ms.mod = tcell.ModNone
+				ms.key = tcell.ButtonNone
+			} else if x != ms.lastX || y != ms.lastY {
+				mouseKey = MouseMove
+				mouseMod = Modifier(tev.Modifiers())
 			}
 		}
+		ms.lastX, ms.lastY = x, y
 
 		return gocuiEvent{
 			Type:   eventMouse,
 			MouseX: x,
 			MouseY: y,
+			StartX: ms.startX,
+			StartY: ms.startY,
+			Clicks: clicks,
 			Key:    mouseKey,
 			Ch:     0,
 			Mod:    mouseMod,
@@ -0,0 +1,825 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// ErrTerminalUnsupported is returned when pseudo-terminals are not supported
+// on the current platform.
+var ErrTerminalUnsupported = errors.New("pseudo-terminals are not supported on this platform")
+
+// TerminalView is a widget running a command in a pseudo-terminal and
+// displaying its output in a view. It emulates the subset of VT100/xterm
+// needed by shells and full screen programs like htop or less.
+//
+// Once the view is created, the keys pressed while it is the current view are
+// forwarded to the command, unless they match a keybinding. Create it from a
+// manager:
+//
+//	tv := gocui.NewTerminalView("shell", exec.Command("bash"))
+//	g.SetManagerFunc(func(g *gocui.Gui) error {
+//		maxX, maxY := g.Size()
+//		_, err := tv.SetView(g, 0, 0, maxX-1, maxY-1)
+//		if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+//			return err
+//		}
+//		return nil
+//	})
+type TerminalView struct {
+	name string
+	cmd  *exec.Cmd
+	pty  *os.File
+	v    *View
+	vt   *vtScreen
+
+	exited  bool
+	exitErr error
+
+	// startErr is the error returned when the command couldn't be started,
+	// it is not started again
+	startErr error
+
+	// input holds the bytes waiting to be written to the pseudo-terminal
+	// by writeLoop, which is signaled by inputReady. done is closed when
+	// the command exited.
+	inputMutex sync.Mutex
+	input      []byte
+	inputReady chan struct{}
+	done       chan struct{}
+
+	// OnExit is called from the main loop when the command exited, with
+	// the error returned by exec.Cmd.Wait.
+	OnExit func(g *Gui, v *View, err error) error
+}
+
+// NewTerminalView returns a TerminalView which runs cmd in a view with the
+// given name. The command is started by the first call to SetView.
+func NewTerminalView(name string, cmd *exec.Cmd) *TerminalView {
+	return &TerminalView{
+		name:       name,
+		cmd:        cmd,
+		inputReady: make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// SetView creates or updates the view of the terminal, like Gui.SetView. The
+// first call starts the command and returns ErrUnknownView along with the
+// view, so it can be initialized. When the dimensions of the view change, the
+// pseudo-terminal is resized.
+func (tv *TerminalView) SetView(g *Gui, x0, y0, x1, y1 int) (*View, error) {
+	if tv.startErr != nil {
+		return nil, tv.startErr
+	}
+	v, err := g.SetView(tv.name, x0, y0, x1, y1, 0)
+	if err != nil && !errors.Is(err, ErrUnknownView) {
+		return nil, err
+	}
+
+	width, height := v.Size()
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	if tv.v == nil {
+		pty, err := startPty(tv.cmd, width, height)
+		if err != nil {
+			// no empty view is left behind
+			tv.startErr = err
+			if err := g.DeleteView(tv.name); err != nil {
+				return nil, err
+			}
+			return nil, tv.startErr
+		}
+		tv.pty = pty
+		tv.v = v
+		tv.vt = newVTScreen(width, height, v.outMode)
+		v.Editable = true
+		v.Editor = tv
+		v.Wrap = false
+		v.Autoscroll = false
+		go tv.readLoop(g)
+		go tv.writeLoop()
+		tv.render()
+		return v, ErrUnknownView
+	}
+
+	if width != tv.vt.width || height != tv.vt.height {
+		tv.vt.resize(width, height)
+		if !tv.exited {
+			if err := resizePty(tv.pty, width, height); err != nil {
+				return nil, err
+			}
+		}
+		tv.render()
+	}
+	return v, nil
+}
+
+// Exited reports whether the command exited and the error returned by
+// exec.Cmd.Wait. The exit status is available from the exec.Cmd.
+func (tv *TerminalView) Exited() (bool, error) {
+	return tv.exited, tv.exitErr
+}
+
+// Close kills the command if it is still running and closes the
+// pseudo-terminal.
+func (tv *TerminalView) Close() error {
+	if tv.pty == nil {
+		return nil
+	}
+	if !tv.exited && tv.cmd.Process != nil {
+		_ = tv.cmd.Process.Kill()
+	}
+	return tv.pty.Close()
+}
+
+// Edit forwards a key press to the command running in the terminal. It
+// implements the Editor interface.
+func (tv *TerminalView) Edit(v *View, key Key, ch rune, mod Modifier) {
+	if tv.pty == nil || tv.exited {
+		return
+	}
+	if b := tv.vt.keySequence(key, ch, mod); len(b) > 0 {
+		tv.send(b)
+	}
+}
+
+// send queues bytes for the command. They are written by writeLoop, so that
+// the main loop is not blocked when the command doesn't read its input.
+func (tv *TerminalView) send(b []byte) {
+	tv.inputMutex.Lock()
+	tv.input = append(tv.input, b...)
+	tv.inputMutex.Unlock()
+	select {
+	case tv.inputReady <- struct{}{}:
+	default:
+		// writeLoop is already signaled
+	}
+}
+
+// writeLoop writes the queued input to the pseudo-terminal until the command
+// exits.
+func (tv *TerminalView) writeLoop() {
+	for {
+		select {
+		case <-tv.inputReady:
+		case <-tv.done:
+			return
+		}
+		tv.inputMutex.Lock()
+		b := tv.input
+		tv.input = nil
+		tv.inputMutex.Unlock()
+		_, _ = tv.pty.Write(b)
+	}
+}
+
+// readLoop reads the output of the command until it exits. The output is
+// processed in the main loop, so the emulator doesn't need any locking.
+func (tv *TerminalView) readLoop(g *Gui) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := tv.pty.Read(buf)
+		if n > 0 {
+			data := make([]byte, n)
+			copy(data, buf[:n])
+			g.UpdateAsync(func(g *Gui) error {
+				tv.vt.write(data)
+				if len(tv.vt.replies) > 0 {
+					tv.send(tv.vt.replies)
+					tv.vt.replies = nil
+				}
+				tv.render()
+				return nil
+			})
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	err := tv.cmd.Wait()
+	close(tv.done)
+	g.UpdateAsync(func(g *Gui) error {
+		tv.exited = true
+		tv.exitErr = err
+		if tv.OnExit != nil {
+			return tv.OnExit(g, tv.v, err)
+		}
+		return nil
+	})
+}
+
+// render copies the screen of the emulator into the view.
+func (tv *TerminalView) render() {
+	v, vt := tv.v, tv.vt
+	lines := make([][]cell, len(vt.lines))
+	for y, row := range vt.lines {
+		line := make([]cell, 0, len(row))
+		for _, c := range row {
+			if c.chr != widePlaceholder {
+				line = append(line, c)
+			}
+		}
+		lines[y] = line
+	}
+
+	v.writeMutex.Lock()
+	v.lines = lines
+	v.version++
+	v.ox, v.oy = 0, 0
+	if vt.cursorHidden {
+		// out of the view, so the cursor is not displayed
+		v.cx, v.cy = len(lines[vt.cy])+1, vt.cy
+	} else {
+		// the cursor of the view is an index in the line, not a column
+		v.cx, v.cy = cellIndexAt(lines[vt.cy], 0, vt.cx, v.tabWidth()), vt.cy
+	}
+	v.tainted = true
+	v.writeMutex.Unlock()
+}
+
+// widePlaceholder fills the cell after a wide character in the emulator
+// screen, it is removed when the screen is copied to the view.
+const widePlaceholder rune = -1
+
+// vtScreen is a minimal VT100/xterm screen emulator.
+type vtScreen struct {
+	width, height int
+	lines         [][]cell
+	ei            *escapeInterpreter
+
+	// cursor position and state
+	cx, cy         int
+	savedX, savedY int
+	wrapNext       bool
+	cursorHidden   bool
+	appCursorKeys  bool
+	autoWrap       bool
+	lineDrawing    bool
+	top, bottom    int // scrolling region
+	pending        []byte
+	mainLines      [][]cell // the main screen while the alternate one is used
+	mainX, mainY   int
+	alternateShown bool
+
+	// replies holds the answers to the reports requested by the command,
+	// e.g. the cursor position, to be sent back to it
+	replies []byte
+}
+
+// newVTScreen returns an emulator screen with the given size.
+func newVTScreen(width, height int, mode OutputMode) *vtScreen {
+	vt := &vtScreen{
+		width:    width,
+		height:   height,
+		ei:       newEscapeInterpreter(mode),
+		autoWrap: true,
+		bottom:   height - 1,
+	}
+	vt.lines = vt.blankLines(height)
+	return vt
+}
+
+// blankLines returns n empty lines of the width of the screen.
+func (vt *vtScreen) blankLines(n int) [][]cell {
+	lines := make([][]cell, n)
+	for i := range lines {
+		lines[i] = vt.blankLine()
+	}
+	return lines
+}
+
+// blankLine returns an empty line using the current background color.
+func (vt *vtScreen) blankLine() []cell {
+	line := make([]cell, vt.width)
+	for i := range line {
+		line[i] = vt.blank()
+	}
+	return line
+}
+
+func (vt *vtScreen) blank() cell {
+	return cell{chr: ' ', bgColor: vt.ei.curBgColor &^ AttrStyleBits}
+}
+
+// resize changes the size of the screen, keeping the top-left content.
+func (vt *vtScreen) resize(width, height int) {
+	vt.width = width
+	resizeLines := func(lines [][]cell) [][]cell {
+		for y, line := range lines {
+			if len(line) > width {
+				lines[y] = line[:width]
+				if last := lines[y][width-1]; last.chr != widePlaceholder && runewidth.RuneWidth(last.chr) > 1 {
+					lines[y][width-1] = vt.blank()
+				}
+			}
+			for len(lines[y]) < width {
+				lines[y] = append(lines[y], vt.blank())
+			}
+		}
+		if len(lines) > height {
+			// keep the bottom of the screen, where the cursor usually is
+			return lines[len(lines)-height:]
+		}
+		for len(lines) < height {
+			lines = append(lines, vt.blankLine())
+		}
+		return lines
+	}
+
+	shift := max(len(vt.lines)-height, 0)
+	vt.cy -= shift
+	vt.lines = resizeLines(vt.lines)
+	// the saved cursor is on the main screen
+	if vt.mainLines != nil {
+		shift = max(len(vt.mainLines)-height, 0)
+		vt.mainY -= shift
+		vt.mainLines = resizeLines(vt.mainLines)
+	}
+	vt.savedY -= shift
+	vt.height = height
+	vt.top, vt.bottom = 0, height-1
+	vt.wrapNext = false
+	vt.clampCursor()
+	vt.mainX, vt.mainY = vt.clamp(vt.mainX, vt.mainY)
+	vt.savedX, vt.savedY = vt.clamp(vt.savedX, vt.savedY)
+}
+
+// clampCursor keeps the cursor on the screen.
+func (vt *vtScreen) clampCursor() {
+	vt.cx, vt.cy = vt.clamp(vt.cx, vt.cy)
+}
+
+// clamp returns the position of the screen which is the closest to x, y.
+func (vt *vtScreen) clamp(x, y int) (int, int) {
+	return max(min(x, vt.width-1), 0), max(min(y, vt.height-1), 0)
+}
+
+// write processes the output of the command.
+func (vt *vtScreen) write(p []byte) {
+	if len(vt.pending) > 0 {
+		p = append(vt.pending, p...)
+		vt.pending = nil
+	}
+	for len(p) > 0 {
+		r, size := utf8.DecodeRune(p)
+		if r == utf8.RuneError && size == 1 && !utf8.FullRune(p) {
+			// incomplete rune, wait for the next write
+			vt.pending = append([]byte(nil), p...)
+			return
+		}
+		p = p[size:]
+		vt.writeRune(r)
+	}
+}
+
+// writeRune processes a rune of the output of the command.
+func (vt *vtScreen) writeRune(r rune) {
+	if vt.ei.state == stateNone {
+		switch r {
+		case '\r':
+			vt.cx = 0
+			vt.wrapNext = false
+			return
+		case '\n', '\v', '\f':
+			vt.lineFeed()
+			return
+		case '\b':
+			if vt.cx > 0 {
+				vt.cx--
+			}
+			vt.wrapNext = false
+			return
+		case '\t':
+			vt.cx = (vt.cx/8 + 1) * 8
+			if vt.cx >= vt.width {
+				vt.cx = vt.width - 1
+			}
+			return
+		case 0x07, 0x0e, 0x0f, 0x00:
+			// bell and character set shifts are ignored
+			return
+		}
+	}
+
+	isEscape, err := vt.ei.parseOne(r)
+	if err != nil {
+		for _, r := range vt.ei.runes() {
+			vt.put(r)
+		}
+		vt.ei.endSequence()
+		return
+	}
+	if isEscape {
+		if ins := vt.ei.takeInstruction(); ins.kind != 0 {
+			vt.execute(ins)
+		}
+		return
+	}
+	vt.put(r)
+}
+
+// decLineDrawing maps the DEC special graphics character set to unicode.
+var decLineDrawing = map[rune]rune{
+	'`': '◆', 'a': '▒', 'f': '°', 'g': '±', 'j': '┘', 'k': '┐', 'l': '┌',
+	'm': '└', 'n': '┼', 'o': '⎺', 'p': '⎻', 'q': '─', 'r': '⎼', 's': '⎽',
+	't': '├', 'u': '┤', 'v': '┴', 'w': '┬', 'x': '│', 'y': '≤', 'z': '≥',
+	'{': 'π', '|': '≠', '}': '£', '~': '·',
+}
+
+// put writes a printable rune at the cursor position.
+func (vt *vtScreen) put(r rune) {
+	if r < 0x20 || r == 0x7f {
+		return
+	}
+	if vt.lineDrawing {
+		if d, ok := decLineDrawing[r]; ok {
+			r = d
+		}
+	}
+	// combining characters and the other runes continuing a grapheme
+	// cluster are added to the last written cell
+	x := vt.cx - 1
+	if vt.wrapNext {
+		x = vt.cx
+	}
+	line := vt.lines[vt.cy]
+	if x > 0 && line[x].chr == widePlaceholder {
+		x--
+	}
+	if x >= 0 && line[x].extendedBy(r) {
+		line[x] = line[x].withRune(r)
+		return
+	}
+	w := runewidth.RuneWidth(r)
+	if w == 0 {
+		return
+	}
+
+	if vt.wrapNext || vt.cx+w > vt.width {
+		if !vt.autoWrap {
+			vt.cx = vt.width - w
+		} else {
+			vt.cx = 0
+			vt.lineFeed()
+		}
+	}
+	vt.wrapNext = false
+
+	line = vt.lines[vt.cy]
+	line[vt.cx] = cell{chr: r, fgColor: vt.ei.curFgColor, bgColor: vt.ei.curBgColor}
+	if w > 1 && vt.cx+1 < vt.width {
+		line[vt.cx+1] = cell{chr: widePlaceholder}
+	}
+	vt.cx += w
+	if vt.cx >= vt.width {
+		vt.cx = vt.width - 1
+		vt.wrapNext = true
+	}
+}
+
+// lineFeed moves the cursor down, scrolling the region if needed.
+func (vt *vtScreen) lineFeed() {
+	vt.wrapNext = false
+	if vt.cy == vt.bottom {
+		vt.scrollUp(1)
+	} else if vt.cy < vt.height-1 {
+		vt.cy++
+	}
+}
+
+// reverseIndex moves the cursor up, scrolling the region if needed.
+func (vt *vtScreen) reverseIndex() {
+	vt.wrapNext = false
+	if vt.cy == vt.top {
+		vt.scrollDown(1)
+	} else if vt.cy > 0 {
+		vt.cy--
+	}
+}
+
+// scrollUp scrolls the scrolling region up by n lines.
+func (vt *vtScreen) scrollUp(n int) {
+	vt.deleteLines(vt.top, n)
+}
+
+// scrollDown scrolls the scrolling region down by n lines.
+func (vt *vtScreen) scrollDown(n int) {
+	vt.insertLines(vt.top, n)
+}
+
+// insertLines inserts n blank lines at line y, shifting the lines below
+// until the bottom of the scrolling region.
+func (vt *vtScreen) insertLines(y, n int) {
+	if y < vt.top || y > vt.bottom {
+		return
+	}
+	if n > vt.bottom-y+1 {
+		n = vt.bottom - y + 1
+	}
+	copy(vt.lines[y+n:vt.bottom+1], vt.lines[y:vt.bottom+1-n])
+	for i := y; i < y+n; i++ {
+		vt.lines[i] = vt.blankLine()
+	}
+}
+
+// deleteLines deletes n lines at line y, shifting the lines below until the
+// bottom of the scrolling region up.
+func (vt *vtScreen) deleteLines(y, n int) {
+	if y < vt.top || y > vt.bottom {
+		return
+	}
+	if n > vt.bottom-y+1 {
+		n = vt.bottom - y + 1
+	}
+	copy(vt.lines[y:vt.bottom+1-n], vt.lines[y+n:vt.bottom+1])
+	for i := vt.bottom + 1 - n; i <= vt.bottom; i++ {
+		vt.lines[i] = vt.blankLine()
+	}
+}
+
+// erase blanks the cells of line y from x0 to x1 (excluded).
+func (vt *vtScreen) erase(y, x0, x1 int) {
+	line := vt.lines[y]
+	if x1 > len(line) {
+		x1 = len(line)
+	}
+	for x := x0; x < x1; x++ {
+		line[x] = vt.blank()
+	}
+}
+
+// switchScreen shows the alternate screen or the main one.
+func (vt *vtScreen) switchScreen(alternate bool) {
+	if alternate == vt.alternateShown {
+		return
+	}
+	vt.alternateShown = alternate
+	if alternate {
+		vt.mainLines, vt.mainX, vt.mainY = vt.lines, vt.cx, vt.cy
+		vt.lines = vt.blankLines(vt.height)
+		return
+	}
+	vt.lines, vt.cx, vt.cy = vt.mainLines, vt.mainX, vt.mainY
+	vt.mainLines = nil
+	vt.clampCursor()
+}
+
+// execute runs a control sequence.
+func (vt *vtScreen) execute(ins instruction) {
+	if ins.esc {
+		vt.executeEscape(ins)
+		return
+	}
+	if ins.private == '?' {
+		if ins.kind == 'h' || ins.kind == 'l' {
+			for _, p := range ins.params {
+				vt.setMode(p, ins.kind == 'h')
+			}
+		}
+		return
+	}
+	if ins.private != 0 {
+		return
+	}
+
+	n := ins.param(0, 1)
+	switch ins.kind {
+	case 'A':
+		vt.cy -= n
+	case 'B', 'e':
+		vt.cy += n
+	case 'C', 'a':
+		vt.cx += n
+	case 'D':
+		vt.cx -= n
+	case 'E':
+		vt.cy += n
+		vt.cx = 0
+	case 'F':
+		vt.cy -= n
+		vt.cx = 0
+	case 'G', '`':
+		vt.cx = n - 1
+	case 'd':
+		vt.cy = n - 1
+	case 'H', 'f':
+		vt.cy = ins.param(0, 1) - 1
+		vt.cx = ins.param(1, 1) - 1
+	case 'J':
+		switch ins.param(0, 0) {
+		case 0:
+			vt.erase(vt.cy, vt.cx, vt.width)
+			for y := vt.cy + 1; y < vt.height; y++ {
+				vt.erase(y, 0, vt.width)
+			}
+		case 1:
+			for y := 0; y < vt.cy; y++ {
+				vt.erase(y, 0, vt.width)
+			}
+			vt.erase(vt.cy, 0, vt.cx+1)
+		case 2, 3:
+			for y := 0; y < vt.height; y++ {
+				vt.erase(y, 0, vt.width)
+			}
+		}
+	case 'K':
+		switch ins.param(0, 0) {
+		case 0:
+			vt.erase(vt.cy, vt.cx, vt.width)
+		case 1:
+			vt.erase(vt.cy, 0, vt.cx+1)
+		case 2:
+			vt.erase(vt.cy, 0, vt.width)
+		}
+	case 'X':
+		vt.erase(vt.cy, vt.cx, vt.cx+n)
+	case 'L':
+		vt.insertLines(vt.cy, n)
+	case 'M':
+		vt.deleteLines(vt.cy, n)
+	case '@':
+		line := vt.lines[vt.cy]
+		if n > vt.width-vt.cx {
+			n = vt.width - vt.cx
+		}
+		copy(line[vt.cx+n:], line[vt.cx:])
+		vt.erase(vt.cy, vt.cx, vt.cx+n)
+	case 'P':
+		line := vt.lines[vt.cy]
+		if n > vt.width-vt.cx {
+			n = vt.width - vt.cx
+		}
+		copy(line[vt.cx:], line[vt.cx+n:])
+		vt.erase(vt.cy, vt.width-n, vt.width)
+	case 'S':
+		vt.scrollUp(n)
+	case 'T':
+		vt.scrollDown(n)
+	case 'r':
+		top, bottom := ins.param(0, 1)-1, ins.param(1, vt.height)-1
+		if top < bottom && bottom < vt.height {
+			vt.top, vt.bottom = top, bottom
+			vt.cx, vt.cy = 0, 0
+		}
+	case 's':
+		vt.savedX, vt.savedY = vt.cx, vt.cy
+	case 'u':
+		vt.cx, vt.cy = vt.savedX, vt.savedY
+	case 'n':
+		// device status report
+		switch ins.param(0, 0) {
+		case 5:
+			vt.replies = append(vt.replies, "\x1b[0n"...)
+		case 6:
+			vt.replies = append(vt.replies, fmt.Sprintf("\x1b[%d;%dR", vt.cy+1, vt.cx+1)...)
+		}
+		return
+	default:
+		return
+	}
+	vt.wrapNext = false
+	vt.clampCursor()
+}
+
+// executeEscape runs an ESC sequence which is not a CSI sequence.
+func (vt *vtScreen) executeEscape(ins instruction) {
+	if ins.private == '(' {
+		vt.lineDrawing = ins.kind == '0'
+		return
+	}
+	if ins.private != 0 {
+		return
+	}
+
+	switch ins.kind {
+	case '7':
+		vt.savedX, vt.savedY = vt.cx, vt.cy
+	case '8':
+		vt.cx, vt.cy = vt.savedX, vt.savedY
+		vt.clampCursor()
+	case 'D':
+		vt.lineFeed()
+	case 'E':
+		vt.cx = 0
+		vt.lineFeed()
+	case 'M':
+		vt.reverseIndex()
+	case 'c':
+		*vt = *newVTScreen(vt.width, vt.height, vt.ei.mode)
+	}
+}
+
+// setMode sets or resets a private mode (CSI ? n h and CSI ? n l).
+func (vt *vtScreen) setMode(mode int, on bool) {
+	switch mode {
+	case 1:
+		vt.appCursorKeys = on
+	case 7:
+		vt.autoWrap = on
+	case 25:
+		vt.cursorHidden = !on
+	case 47, 1047:
+		vt.switchScreen(on)
+	case 1049:
+		if on {
+			vt.savedX, vt.savedY = vt.cx, vt.cy
+			vt.switchScreen(true)
+		} else {
+			vt.switchScreen(false)
+			vt.cx, vt.cy = vt.savedX, vt.savedY
+			vt.clampCursor()
+		}
+	}
+}
+
+// keySequence returns the bytes sent to the command for a key press.
+func (vt *vtScreen) keySequence(key Key, ch rune, mod Modifier) []byte {
+	var b []byte
+	if mod&ModAlt != 0 {
+		b = append(b, 0x1b)
+	}
+	if ch != 0 {
+		return append(b, string(ch)...)
+	}
+
+	cursor := func(c byte) []byte {
+		if vt.appCursorKeys {
+			return append(b, 0x1b, 'O', c)
+		}
+		return append(b, 0x1b, '[', c)
+	}
+
+	switch key {
+	case KeyArrowUp:
+		return cursor('A')
+	case KeyArrowDown:
+		return cursor('B')
+	case KeyArrowRight:
+		return cursor('C')
+	case KeyArrowLeft:
+		return cursor('D')
+	case KeyHome:
+		return cursor('H')
+	case KeyEnd:
+		return cursor('F')
+	case KeyInsert:
+		return append(b, "\x1b[2~"...)
+	case KeyDelete:
+		return append(b, "\x1b[3~"...)
+	case KeyPgup:
+		return append(b, "\x1b[5~"...)
+	case KeyPgdn:
+		return append(b, "\x1b[6~"...)
+	case KeyBacktab:
+		return append(b, "\x1b[Z"...)
+	case KeyBackspace, KeyBackspace2:
+		return append(b, 0x7f)
+	case KeyF1:
+		return append(b, "\x1bOP"...)
+	case KeyF2:
+		return append(b, "\x1bOQ"...)
+	case KeyF3:
+		return append(b, "\x1bOR"...)
+	case KeyF4:
+		return append(b, "\x1bOS"...)
+	case KeyF5:
+		return append(b, "\x1b[15~"...)
+	case KeyF6:
+		return append(b, "\x1b[17~"...)
+	case KeyF7:
+		return append(b, "\x1b[18~"...)
+	case KeyF8:
+		return append(b, "\x1b[19~"...)
+	case KeyF9:
+		return append(b, "\x1b[20~"...)
+	case KeyF10:
+		return append(b, "\x1b[21~"...)
+	case KeyF11:
+		return append(b, "\x1b[23~"...)
+	case KeyF12:
+		return append(b, "\x1b[24~"...)
+	}
+
+	// control keys, enter, tab, escape and space map to their ASCII code
+	if key <= 0x7f {
+		return append(b, byte(key))
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func vtLines(vt *vtScreen) []string {
+	var lines []string
+	for _, row := range vt.lines {
+		var b strings.Builder
+		for _, c := range row {
+			if c.chr != widePlaceholder {
+				b.WriteRune(c.chr)
+			}
+		}
+		lines = append(lines, strings.TrimRight(b.String(), " "))
+	}
+	return lines
+}
+
+func TestVTScreen(t *testing.T) {
+	tests := []struct {
+		input  string
+		lines  []string
+		cx, cy int
+	}{
+		{"hello\r\nworld", []string{"hello", "world", ""}, 4, 1},
+		{"abcdefg", []string{"abcde", "fg", ""}, 2, 1},
+		{"1\r\n2\r\n3\r\n4", []string{"2", "3", "4"}, 1, 2},
+		{"abc\x1b[2;3Hx\x1b[1;2H\x1b[K", []string{"a", "  x", ""}, 1, 0},
+		{"a\x1b[?1049hb\x1b[?1049l", []string{"a", "", ""}, 1, 0},
+		{"\x1b(0lqk\x1b(Bq", []string{"┌─┐q", "", ""}, 4, 0},
+		{"1\r\n2\r\n3\x1b[1;2r\x1b[2;1H\n", []string{"2", "", "3"}, 0, 1},
+	}
+
+	for _, test := range tests {
+		vt := newVTScreen(5, 3, OutputNormal)
+		vt.write([]byte(test.input))
+		if got := vtLines(vt); strings.Join(got, "|") != strings.Join(test.lines, "|") {
+			t.Errorf("%q: got lines %q, want %q", test.input, got, test.lines)
+		}
+		if vt.cx != test.cx || vt.cy != test.cy {
+			t.Errorf("%q: got cursor %d,%d, want %d,%d", test.input, vt.cx, vt.cy, test.cx, test.cy)
+		}
+	}
+}
+
+func TestVTScreenReports(t *testing.T) {
+	vt := newVTScreen(5, 3, OutputNormal)
+	vt.write([]byte("ab\r\ncd\x1b[6n\x1b[5n"))
+	if got, want := string(vt.replies), "\x1b[2;3R\x1b[0n"; got != want {
+		t.Errorf("got replies %q, want %q", got, want)
+	}
+}
+
+func TestTerminalViewInput(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	tv := NewTerminalView("test", nil)
+	tv.pty = w
+	tv.vt = newVTScreen(5, 3, OutputNormal)
+	go tv.writeLoop()
+	defer close(tv.done)
+
+	// the keys are queued without waiting for the command to read them
+	tv.Edit(nil, 0, 'a', ModNone)
+	tv.Edit(nil, KeyEnter, 0, ModNone)
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf); got != "a\r" {
+		t.Errorf("got input %q, want %q", got, "a\r")
+	}
+}
+
+func TestVTScreenResizeMainScreen(t *testing.T) {
+	vt := newVTScreen(5, 3, OutputNormal)
+	vt.write([]byte("1\r\n2\r\n3\x1b[1A\x1b[?1049h"))
+	vt.resize(5, 2)
+	vt.write([]byte("\x1b[?1049l"))
+	if got := vtLines(vt); strings.Join(got, "|") != "2|3" {
+		t.Errorf("got lines %q, want the bottom of the main screen", got)
+	}
+	// the cursor is back on its line
+	if vt.cx != 1 || vt.cy != 0 {
+		t.Errorf("got cursor %d,%d, want 1,0", vt.cx, vt.cy)
+	}
+}
+
+func TestTerminalViewStartError(t *testing.T) {
+	g := &Gui{}
+	tv := NewTerminalView("term", exec.Command("/nonexistent/command"))
+	for i := 0; i < 2; i++ {
+		if _, err := tv.SetView(g, 0, 0, 10, 5); err == nil {
+			t.Fatal("got no error for a command which can't be started")
+		}
+		if _, err := g.View("term"); err == nil {
+			t.Error("got a view for a command which can't be started")
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Role is the name of a color role of a Theme. Applications can define
+// their own roles, e.g. to style the text they write with View.RoleStyle.
+type Role string
+
+// Roles used by gocui.
+const (
+	// RoleText is the style of the text and background of views and GUI.
+	RoleText Role = "text"
+
+	// RoleMuted, RoleError, RoleWarning and RoleSuccess are not used by
+	// gocui, they are meant to be used by applications for their text.
+	RoleMuted   Role = "muted"
+	RoleError   Role = "error"
+	RoleWarning Role = "warning"
+	RoleSuccess Role = "success"
+
+	// RoleSelection is the style of the selected line of views with
+	// Highlight enabled.
+	RoleSelection Role = "selection"
+
+	// RoleFrame and RoleTitle are the styles of the frames and titles of
+	// the views. Only the foreground is used, the background is the one of
+	// RoleText.
+	RoleFrame Role = "frame"
+	RoleTitle Role = "title"
+
+	// RoleFocusedFrame and RoleFocusedTitle are the styles of the frame and
+	// title of the current view when Gui.Highlight is set.
+	RoleFocusedFrame Role = "focused-frame"
+	RoleFocusedTitle Role = "focused-title"
+
+	// RoleScrollbar is the style of the thumb of scrollbars.
+	RoleScrollbar Role = "scrollbar"
+
+	// RoleMatch and RoleCurrentMatch are the styles of the matches of the
+	// search of views. The default styles of views are used when they are
+	// missing from the theme.
+	RoleMatch        Role = "match"
+	RoleCurrentMatch Role = "current-match"
+
+	// RoleTextSelection is the style of the text selected with the mouse.
+	// The default style of views is used when it is missing from the theme.
+	RoleTextSelection Role = "text-selection"
+
+	// RoleLineNumber and RoleCurrentLineNumber are the styles of the line
+	// numbers in the gutter of views, and of the number of the line of the
+	// cursor. They are dim and normal when missing from the theme.
+	RoleLineNumber        Role = "line-number"
+	RoleCurrentLineNumber Role = "current-line-number"
+
+	// RoleKeyword, RoleString, RoleNumber, RoleComment and RoleProperty
+	// are the styles of the tokens highlighted by JSONHighlighter and
+	// GoHighlighter. Default styles are used when they are missing from
+	// the theme.
+	RoleKeyword  Role = "keyword"
+	RoleString   Role = "string"
+	RoleNumber   Role = "number"
+	RoleComment  Role = "comment"
+	RoleProperty Role = "property"
+)
+
+// Theme associates styles to roles. A missing role uses the default colors.
+type Theme map[Role]Style
+
+// Style returns the style of a role.
+func (t Theme) Style(role Role) Style {
+	return t[role]
+}
+
+// LoadTheme reads a theme from a JSON file, see ParseTheme.
+func LoadTheme(filename string) (Theme, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTheme(data)
+}
+
+// ParseTheme parses a theme in JSON. Roles are mapped to objects with
+// optional "fg", "bg" and "attrs" members. Colors are W3C color names,
+// "#rrggbb" values, numbers of the 256-colors palette or "default", e.g.:
+//
+//	{
+//		"text": {"fg": "#d0d0d0", "bg": "#1c1c1c"},
+//		"focused-frame": {"fg": "lime", "attrs": ["bold"]},
+//		"error": {"fg": "196"}
+//	}
+func ParseTheme(data []byte) (Theme, error) {
+	var roles map[Role]struct {
+		Fg    string   `json:"fg"`
+		Bg    string   `json:"bg"`
+		Attrs []string `json:"attrs"`
+	}
+	if err := json.Unmarshal(data, &roles); err != nil {
+		return nil, err
+	}
+
+	theme := make(Theme, len(roles))
+	for role, s := range roles {
+		fg, err := parseThemeColor(s.Fg)
+		if err != nil {
+			return nil, fmt.Errorf("role %q: %w", role, err)
+		}
+		bg, err := parseThemeColor(s.Bg)
+		if err != nil {
+			return nil, fmt.Errorf("role %q: %w", role, err)
+		}
+		for _, name := range s.Attrs {
+			attr, ok := themeAttributes[strings.ToLower(name)]
+			if !ok {
+				return nil, fmt.Errorf("role %q: unknown attribute %q", role, name)
+			}
+			fg |= attr
+		}
+		theme[role] = Style{Fg: fg, Bg: bg}
+	}
+	return theme, nil
+}
+
+// themeAttributes are the text attributes accepted by ParseTheme.
+var themeAttributes = map[string]Attribute{
+	"bold":          AttrBold,
+	"blink":         AttrBlink,
+	"reverse":       AttrReverse,
+	"underline":     AttrUnderline,
+	"dim":           AttrDim,
+	"italic":        AttrItalic,
+	"strikethrough": AttrStrikeThrough,
+}
+
+// parseThemeColor parses a color of a theme file.
+func parseThemeColor(s string) (Attribute, error) {
+	if s == "" || strings.EqualFold(s, "default") {
+		return ColorDefault, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 || n > 255 {
+			return 0, fmt.Errorf("invalid color %q", s)
+		}
+		return Get256Color(int32(n)), nil
+	}
+	c := GetColor(s)
+	if c == ColorDefault {
+		return 0, fmt.Errorf("invalid color %q", s)
+	}
+	return c, nil
+}
+
+// SetTheme sets the theme of the GUI and applies it to all its views. The
+// colors of the GUI and views are replaced by the ones of the theme, except
+// the roles overridden by views with View.SetRoleStyle. The views created
+// afterwards also use the theme. A nil theme restores the default colors.
+func (g *Gui) SetTheme(theme Theme) {
+	g.theme = theme
+
+	g.FgColor, g.BgColor = theme[RoleText].Fg, theme[RoleText].Bg
+	g.FrameColor = theme[RoleFrame].Fg
+	g.SelFgColor = theme[RoleFocusedTitle].Fg
+	g.SelFrameColor, g.SelBgColor = theme[RoleFocusedFrame].Fg, theme[RoleFocusedFrame].Bg
+
+	for _, v := range g.views {
+		v.applyTheme()
+	}
+}
+
+// Theme returns the theme of the GUI, or nil if SetTheme was not called.
+func (g *Gui) Theme() Theme {
+	return g.theme
+}
+
+// SetRoleStyle overrides the style of a role of the theme for this view.
+func (v *View) SetRoleStyle(role Role, style Style) {
+	if v.roleStyles == nil {
+		v.roleStyles = make(Theme)
+	}
+	v.roleStyles[role] = style
+	v.applyTheme()
+}
+
+// ResetRoleStyle removes the override of the style of a role for this view.
+func (v *View) ResetRoleStyle(role Role) {
+	delete(v.roleStyles, role)
+	v.applyTheme()
+}
+
+// RoleStyle returns the style of a role for this view: the style set with
+// SetRoleStyle, or the style of the theme of the GUI.
+func (v *View) RoleStyle(role Role) Style {
+	s, _ := v.lookupRole(role)
+	return s
+}
+
+// lookupRole returns the style of a role for this view, and whether it is
+// defined.
+func (v *View) lookupRole(role Role) (Style, bool) {
+	if s, ok := v.roleStyles[role]; ok {
+		return s, true
+	}
+	s, ok := v.gui.theme[role]
+	return s, ok
+}
+
+// applyTheme sets the colors of the view from the theme of the GUI and the
+// roles overridden by the view. The colors taken from a previous theme are
+// reset to the defaults first, the ones set by the application are kept if
+// the view never had a theme.
+func (v *View) applyTheme() {
+	if v.themed {
+		v.resetColors()
+		v.tainted = true
+	}
+	v.themed = v.gui.theme != nil || v.roleStyles != nil
+	if !v.themed {
+		return
+	}
+
+	text := v.RoleStyle(RoleText)
+	v.FgColor, v.BgColor = text.Fg, text.Bg
+	sel := v.RoleStyle(RoleSelection)
+	v.SelFgColor, v.SelBgColor = sel.Fg, sel.Bg
+	v.FrameColor = v.RoleStyle(RoleFrame).Fg
+	v.TitleColor = v.RoleStyle(RoleTitle).Fg
+	scrollbar := v.RoleStyle(RoleScrollbar)
+	v.ScrollbarFgColor, v.ScrollbarBgColor = scrollbar.Fg, scrollbar.Bg
+	if s, ok := v.lookupRole(RoleMatch); ok {
+		v.MatchStyle = s
+	}
+	if s, ok := v.lookupRole(RoleCurrentMatch); ok {
+		v.CurrentMatchStyle = s
+	}
+	if s, ok := v.lookupRole(RoleTextSelection); ok {
+		v.SelectionStyle = s
+	}
+	v.tainted = true
+}
+
+// roleStyleOr returns the style of a role for this view, or def if the role
+// is not defined.
+func (v *View) roleStyleOr(role Role, def Style) Style {
+	if s, ok := v.lookupRole(role); ok {
+		return s
+	}
+	return def
+}
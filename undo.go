@@ -0,0 +1,240 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+// undoLimit is the maximum number of steps kept in the undo history of a
+// view.
+const undoLimit = 1000
+
+// editKind identifies the edits which are grouped in a single undo step when
+// they follow each other.
+type editKind int
+
+const (
+	editOther editKind = iota
+	editInsert
+	editDeleteBack
+	editDeleteForward
+)
+
+// lineChange is a change of the buffer of a view saved in its undo
+// history: the lines old, from the line y, were replaced by the lines new.
+// The lines are copies, which are not modified by later edits.
+type lineChange struct {
+	y        int
+	old, new [][]cell
+}
+
+// editPositions holds the cursor and origin of a view.
+type editPositions struct {
+	cx, cy, ox, oy int
+}
+
+// editStep is a step of the undo history: the changes of the buffer made by
+// one or several grouped edits, and the positions before and after them.
+type editStep struct {
+	changes       []lineChange
+	before, after editPositions
+}
+
+// editHistory is the undo and redo history of an editable view.
+type editHistory struct {
+	undo, redo []editStep
+
+	// group is the kind of the last edit, the next edit is part of the same
+	// step if it has the same kind and happens at the cursor position left
+	// by the last one, gx and gy
+	group  editKind
+	gx, gy int
+}
+
+// edit runs f, which modifies the buffer, saving the lines it changes in the
+// undo history if the view is editable. f may only change the lines from y0
+// to y1, insert lines after y0 and delete lines up to y1: the lines after y1
+// are kept.
+func (v *View) edit(kind editKind, y0, y1 int, f func()) {
+	if !v.Editable {
+		f()
+		v.version++
+		v.selection = nil
+		return
+	}
+
+	h := &v.history
+	y0 = max(min(y0, len(v.lines)), 0)
+	y1 = max(min(y1, len(v.lines)-1), y0-1)
+	c := lineChange{y: y0, old: copyLines(v.lines[y0 : y1+1])}
+	kept := len(v.lines) - y1 - 1
+	positions := v.editPositions()
+	f()
+	c.new = copyLines(v.lines[y0 : len(v.lines)-kept])
+	if linesEqual(c.old, c.new) {
+		return
+	}
+	v.version++
+	// the selected text changed
+	v.selection = nil
+
+	grouped := kind != editOther && kind == h.group && positions.cx == h.gx && positions.cy == h.gy && len(h.undo) > 0
+	if grouped {
+		step := &h.undo[len(h.undo)-1]
+		last := &step.changes[len(step.changes)-1]
+		if c.y == last.y && len(c.old) == len(last.new) {
+			// the edit changed the lines of the last one again
+			last.new = c.new
+		} else {
+			step.changes = append(step.changes, c)
+		}
+		step.after = v.editPositions()
+	} else {
+		h.undo = append(h.undo, editStep{changes: []lineChange{c}, before: positions, after: v.editPositions()})
+		if len(h.undo) > undoLimit {
+			h.undo[0] = editStep{}
+			h.undo = h.undo[1:]
+		}
+	}
+	h.redo = nil
+	h.group, h.gx, h.gy = kind, v.cx, v.cy
+}
+
+// linesEqual reports whether the lines a and b have the same cells.
+func linesEqual(a, b [][]cell) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !lineEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// lineEqual reports whether the lines a and b have the same cells.
+func lineEqual(a, b []cell) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for x, c := range a {
+		d := b[x]
+		if c.chr != d.chr || c.fgColor != d.fgColor || c.bgColor != d.bgColor || string(c.comb) != string(d.comb) {
+			return false
+		}
+	}
+	return true
+}
+
+// copyLines returns a copy of lines and of their cells.
+func copyLines(lines [][]cell) [][]cell {
+	copied := make([][]cell, len(lines))
+	for i, line := range lines {
+		copied[i] = append([]cell(nil), line...)
+	}
+	return copied
+}
+
+// replaceLines replaces n lines of the buffer from the line y by a copy of
+// lines. The signs follow their lines: the lines are inserted or deleted
+// after the ones which didn't change, like when lines are split or joined.
+func (v *View) replaceLines(y, n int, lines [][]cell) {
+	old := v.lines[y : y+n]
+	p := 0
+	for p < len(old) && p < len(lines) && lineEqual(old[p], lines[p]) {
+		p++
+	}
+	s := 0
+	for s < len(old)-p && s < len(lines)-p && lineEqual(old[len(old)-1-s], lines[len(lines)-1-s]) {
+		s++
+	}
+	v.shiftSigns(y+p+min(len(old), len(lines))-p-s, len(lines)-len(old))
+
+	rest := v.lines[y+n:]
+	v.lines = append(append(v.lines[:y:y], copyLines(lines)...), rest...)
+	v.version++
+}
+
+// editPositions returns the cursor and origin of the view.
+func (v *View) editPositions() editPositions {
+	return editPositions{cx: v.cx, cy: v.cy, ox: v.ox, oy: v.oy}
+}
+
+// restoreEditPositions sets the cursor and origin of the view after an undo
+// or a redo.
+func (v *View) restoreEditPositions(p editPositions) {
+	v.cx, v.cy, v.ox, v.oy = p.cx, p.cy, p.ox, p.oy
+	v.selection = nil
+	v.tainted = true
+	v.history.group = editOther
+}
+
+// applies reports whether the changes can be applied in order to the buffer,
+// or reverted in reverse order if undo is true: the buffer may have been
+// changed by writes since they were saved.
+func (v *View) applies(changes []lineChange, undo bool) bool {
+	n := len(v.lines)
+	for i := range changes {
+		c := changes[i]
+		from, to := c.old, c.new
+		if undo {
+			c = changes[len(changes)-1-i]
+			from, to = c.new, c.old
+		}
+		if c.y+len(from) > n {
+			return false
+		}
+		n += len(to) - len(from)
+	}
+	return true
+}
+
+// Undo reverts the last edit of an editable view. Consecutive characters
+// typed or deleted are reverted together. It returns false if there is
+// nothing to undo.
+func (v *View) Undo() bool {
+	h := &v.history
+	if len(h.undo) == 0 {
+		return false
+	}
+	step := h.undo[len(h.undo)-1]
+	if !v.applies(step.changes, true) {
+		v.ClearHistory()
+		return false
+	}
+	h.undo = h.undo[:len(h.undo)-1]
+	for i := len(step.changes) - 1; i >= 0; i-- {
+		c := step.changes[i]
+		v.replaceLines(c.y, len(c.new), c.old)
+	}
+	h.redo = append(h.redo, step)
+	v.restoreEditPositions(step.before)
+	return true
+}
+
+// Redo applies again the last edit reverted by Undo. It returns false if
+// there is nothing to redo.
+func (v *View) Redo() bool {
+	h := &v.history
+	if len(h.redo) == 0 {
+		return false
+	}
+	step := h.redo[len(h.redo)-1]
+	if !v.applies(step.changes, false) {
+		v.ClearHistory()
+		return false
+	}
+	h.redo = h.redo[:len(h.redo)-1]
+	for _, c := range step.changes {
+		v.replaceLines(c.y, len(c.old), c.new)
+	}
+	h.undo = append(h.undo, step)
+	v.restoreEditPositions(step.after)
+	return true
+}
+
+// ClearHistory empties the undo and redo history of the view, e.g. after its
+// content was saved.
+func (v *View) ClearHistory() {
+	v.history = editHistory{}
+}
@@ -10,6 +10,7 @@ import (
 	"io"
 	"strings"
 	"sync"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/mattn/go-runewidth"
@@ -29,6 +30,10 @@ var (
 	ErrInvalidPoint = errors.New("invalid point")
 )
 
+// defaultTabWidth is the number of columns between tab stops when the
+// TabWidth of a view is not set.
+const defaultTabWidth = 4
+
 // A View is a window. It maintains its own internal buffer and cursor
 // position.
 type View struct {
@@ -57,6 +62,27 @@ type View struct {
 	// ei is used to decode ESC sequences on Write
 	ei *escapeInterpreter
 
+	// autoscrollPaused is true when the user scrolled up in an Autoscroll
+	// view, it is reset once the view is scrolled back to the bottom
+	autoscrollPaused bool
+
+	// rectOverride is true when the view was moved or resized with the
+	// mouse, in which case SetView no longer changes its dimensions
+	rectOverride bool
+
+	// contentHeight and contentWidth are the number of view lines and the
+	// width of the longest one, as computed during the last draw
+	contentHeight, contentWidth int
+
+	// drawnGutterWidth is the width of the gutter, computed once by draw
+	// for all the cells it sets
+	drawnGutterWidth int
+
+	// highlightY is the line of the view highlighted when Highlight is
+	// set: the one showing the cursor in editable views, the line of the
+	// cursor otherwise
+	highlightY int
+
 	// Visible specifies whether the view is visible.
 	Visible bool
 
@@ -110,10 +136,58 @@ type View struct {
 	// view's x-origin will be ignored.
 	Wrap bool
 
+	// If WordWrap is true, the lines wrapped in Wrap mode are broken at
+	// spaces and other line break opportunities instead of at the last
+	// column. Words longer than the width of the view are still broken.
+	WordWrap bool
+
+	// WrapIndent is the number of columns by which the continuation lines
+	// of the lines wrapped in Wrap mode are indented.
+	WrapIndent int
+
+	// WrapMarker, if not empty, is drawn at the beginning of the
+	// continuation lines of the lines wrapped in Wrap mode, e.g. "↪ ".
+	WrapMarker string
+
+	// TabWidth is the number of columns between the tab stops at which
+	// the tabs of the buffer are drawn. It defaults to 4.
+	TabWidth int
+
+	// TabMarker, if not zero, is drawn in the first column of the tabs of
+	// the buffer to make them visible, e.g. '→'.
+	TabMarker rune
+
+	// LineNumbers sets the kind of line numbers shown in a gutter at the
+	// left of the view. The gutter reduces the width returned by Size.
+	LineNumbers LineNumberMode
+
+	// If SignColumn is true, the gutter at the left of the view has a
+	// column of two cells showing the signs set with SetSign.
+	SignColumn bool
+
+	// Styler, if not nil, styles the visible lines of the view when it is
+	// drawn, e.g. JSONHighlighter or GoHighlighter. The buffer keeps the
+	// colors it was written with.
+	Styler Styler
+
 	// If Autoscroll is true, the View will automatically scroll down when the
 	// text overflows. If true the view's y-origin will be ignored.
 	Autoscroll bool
 
+	// If ControlSequences is true, backspace and the cursor control escape
+	// sequences written to the view move the write position or erase parts
+	// of the buffer like in a terminal, e.g. to redraw progress bars. The
+	// supported sequences are cursor up/down/forward/back (CSI A-D), next
+	// and previous line (CSI E, F), column (CSI G), position (CSI H, f,
+	// relative to the start of the buffer), erase in display (CSI J) and
+	// erase in line (CSI K).
+	ControlSequences bool
+
+	// If MouseScroll is true, the mouse wheel scrolls the view when the
+	// pointer is over it and no keybinding handles the wheel event.
+	// Scrolling up pauses Autoscroll until the bottom is reached again.
+	MouseScroll bool
+
 	// If Frame is true, Title allows to configure a title for the view.
 	Title string
 
@@ -130,6 +204,57 @@ type View struct {
 	// Overlaps describes which edges are overlapping with another view's edges
 	Overlaps byte
 
+	// If Draggable is true and Frame is true, the view can be moved by
+	// dragging its title bar with the left mouse button.
+	Draggable bool
+
+	// If Resizable is true and Frame is true, the view can be resized by
+	// dragging the edges and corners of its frame with the left mouse button.
+	Resizable bool
+
+	// If VerticalScrollbar and HorizontalScrollbar are true and Frame is
+	// true, scrollbars are drawn over the right and bottom edges of the frame
+	// when the content doesn't fit in the view. Their thumb can be dragged
+	// with the mouse to scroll the view.
+	VerticalScrollbar, HorizontalScrollbar bool
+
+	// ScrollbarRunes allows to define custom runes for the scrollbars: the
+	// thumb, optionally followed by the track. By default the thumb is '█',
+	// or '#' in ASCII mode, and the frame edge is used as track.
+	ScrollbarRunes []rune
+
+	// ScrollbarFgColor and ScrollbarBgColor allow to configure the colors of
+	// the scrollbar thumb. The frame colors are used by default.
+	ScrollbarFgColor, ScrollbarBgColor Attribute
+
+	// MatchStyle and CurrentMatchStyle are the styles of the matches of the
+	// search, see Search. Their colors are combined with the colors of the
+	// text when they are ColorDefault.
+	MatchStyle, CurrentMatchStyle Style
+
+	// If Selectable is true, text can be selected with the left mouse
+	// button: dragging selects characters, a double click selects a word and
+	// a triple click selects a line. See SelectedText and CopySelection.
+	Selectable bool
+
+	// SelectionStyle is the style of the text selected with the mouse.
+	SelectionStyle Style
+
+	// MaxLines limits the number of lines kept in the buffer, unless it is
+	// 0. When text written to the view goes past the limit, the oldest lines
+	// are dropped and the origin, cursor, read and write positions are moved
+	// so that they stay on the same text.
+	MaxLines int
+
+	// Completer, if not nil, proposes completions for an editable view in a
+	// popup opened with Tab. The candidates are filtered as the user types,
+	// Tab and the arrows select one and Enter inserts it through the Editor.
+	Completer Completer
+
+	// If AutoComplete is true, the completion popup opens as the user types,
+	// without pressing Tab.
+	AutoComplete bool
+
 	// If HasLoader is true, the message will be appended with a spinning loader animation
 	HasLoader bool
 
@@ -137,17 +262,43 @@ type View struct {
 	// (this is usually not the case)
 	KeybindOnEdit bool
 
+	// search is the active search, see Search
+	search *viewSearch
+
+	// version changes each time the text of the buffer changes
+	version uint64
+
+	// selection is the text selected with the mouse, if any
+	selection *bufferRange
+
+	// history is the undo history of editable views
+	history editHistory
+
+	// roleStyles holds the theme roles overridden with SetRoleStyle
+	roleStyles Theme
+
+	// themed is true if the colors of the view were set from a theme
+	themed bool
+
+	// signs holds the signs of the sign column by buffer line
+	signs map[int]Sign
+
 	// gui contains the view it's gui
 	gui *Gui
 }
 
+// cell holds a grapheme cluster: its first rune is chr and the following
+// ones, like combining accents, emoji modifiers or the parts of a ZWJ emoji
+// sequence, are in comb.
 type cell struct {
 	chr              rune
+	comb             []rune
 	bgColor, fgColor Attribute
 }
 
 type cellCache struct {
 	chr              rune
+	comb             []rune
 	bgColor, fgColor Attribute
 	x, y             int
 }
@@ -156,11 +307,14 @@ type lineType []cell
 
 // String returns a string from a given cell slice.
 func (l lineType) String() string {
-	str := ""
+	var b strings.Builder
 	for _, c := range l {
-		str += string(c.chr)
+		b.WriteRune(c.chr)
+		for _, r := range c.comb {
+			b.WriteRune(r)
+		}
 	}
-	return str
+	return b.String()
 }
 
 // newView returns a new View object.
@@ -179,11 +333,19 @@ func (g *Gui) newView(name string, x0, y0, x1, y1 int, mode OutputMode) *View {
 		ei:      newEscapeInterpreter(mode),
 		gui:     g,
 	}
+	v.resetColors()
+	return v
+}
 
+// resetColors sets the default colors and styles of the view.
+func (v *View) resetColors() {
 	v.FgColor, v.BgColor = ColorDefault, ColorDefault
 	v.SelFgColor, v.SelBgColor = ColorDefault, ColorDefault
 	v.TitleColor, v.FrameColor = ColorDefault, ColorDefault
-	return v
+	v.ScrollbarFgColor, v.ScrollbarBgColor = ColorDefault, ColorDefault
+	v.MatchStyle = Style{Fg: AttrReverse}
+	v.CurrentMatchStyle = Style{Fg: ColorBlack, Bg: ColorYellow}
+	v.SelectionStyle = Style{Fg: AttrReverse}
 }
 
 // Dimensions returns the dimensions of the View
@@ -191,9 +353,16 @@ func (v *View) Dimensions() (int, int, int, int) {
 	return v.x0, v.y0, v.x1, v.y1
 }
 
+// ResetDimensions discards the dimensions set by moving or resizing the view
+// with the mouse, so the next call to SetView applies the given dimensions
+// again.
+func (v *View) ResetDimensions() {
+	v.rectOverride = false
+}
+
 // Size returns the number of visible columns and rows in the View.
 func (v *View) Size() (x, y int) {
-	return v.x1 - v.x0 - 1, v.y1 - v.y0 - 1
+	return v.x1 - v.x0 - 1 - v.gutterWidth(), v.y1 - v.y0 - 1
 }
 
 // Name returns the name of the view.
@@ -204,8 +373,8 @@ func (v *View) Name() string {
 // setRune sets a rune at the given point relative to the view. It applies the
 // specified colors, taking into account if the cell must be highlighted. Also,
 // it checks if the position is valid.
-func (v *View) setRune(x, y int, ch rune, fgColor, bgColor Attribute) error {
-	maxX, maxY := v.Size()
+func (v *View) setRune(x, y int, ch rune, comb []rune, fgColor, bgColor Attribute) error {
+	maxX, maxY := v.x1-v.x0-1-v.drawnGutterWidth, v.y1-v.y0-1
 	if x < 0 || x >= maxX || y < 0 || y >= maxY {
 		return ErrInvalidPoint
 	}
@@ -213,8 +382,8 @@ func (v *View) setRune(x, y int, ch rune, fgColor, bgColor Attribute) error {
 	if v.Mask != 0 {
 		fgColor = v.FgColor
 		bgColor = v.BgColor
-		ch = v.Mask
-	} else if v.Highlight && y == v.cy {
+		ch, comb = v.Mask, nil
+	} else if v.Highlight && y == v.highlightY {
 		fgColor = v.SelFgColor | AttrBold
 		bgColor = v.SelBgColor | AttrBold
 	}
@@ -224,7 +393,7 @@ func (v *View) setRune(x, y int, ch rune, fgColor, bgColor Attribute) error {
 		ch = ' '
 	}
 
-	tcellSetCell(v.x0+x+1, v.y0+y+1, ch, fgColor, bgColor, v.outMode)
+	tcellSetCell(v.x0+v.drawnGutterWidth+x+1, v.y0+y+1, ch, comb, fgColor, bgColor, v.outMode)
 
 	return nil
 }
@@ -247,6 +416,7 @@ func (v *View) SetCursorUnrestricted(x, y int) error {
 
 // SetCursor tries sets the cursor position of the view at the given point
 // If the x or y are outside of the buffer this function will place the cursor on the nearest buffer location
+// x is an index in the line y, see ColumnToBufferX to convert a display column
 //
 // Rules:
 //   y >= 0
@@ -269,11 +439,39 @@ func (v *View) SetCursor(x, y int) error {
 	return v.SetCursorUnrestricted(x, y)
 }
 
-// Cursor returns the cursor position of the view.
+// Cursor returns the cursor position of the view. x is the index of the
+// character in the line y of the buffer, which is not its column on the
+// screen when the line holds wide characters, see BufferXToColumn.
 func (v *View) Cursor() (x, y int) {
 	return v.cx, v.cy
 }
 
+// BufferXToColumn returns the display column of the character at index x of
+// the line y of the buffer, counted from the start of the line: the sum of
+// the widths of the characters before it. Positions after the end of the
+// line are one column apart.
+func (v *View) BufferXToColumn(x, y int) int {
+	if y < 0 || y >= len(v.lines) {
+		return x
+	}
+	line := v.lines[y]
+	if x <= len(line) {
+		return lineWidth(line[:max(x, 0)], v.tabWidth())
+	}
+	return lineWidth(line, v.tabWidth()) + x - len(line)
+}
+
+// ColumnToBufferX returns the index in the line y of the buffer of the
+// character displayed at the display column col, counted from the start of
+// the line. Both columns of a wide character give its index. Columns after
+// the end of the line give the length of the line.
+func (v *View) ColumnToBufferX(col, y int) int {
+	if y < 0 || y >= len(v.lines) {
+		return 0
+	}
+	return cellIndexAt(v.lines[y], 0, col, v.tabWidth())
+}
+
 // SetOrigin sets the origin position of the view's internal buffer,
 // so the buffer starts to be printed from this point, which means that
 // it is linked with the origin point of view. It can be used to
@@ -293,6 +491,143 @@ func (v *View) Origin() (x, y int) {
 	return v.ox, v.oy
 }
 
+// bottomOrigin returns the origin showing the last of the given view lines
+// at the bottom of a view of height maxY, where Autoscroll keeps the view. A
+// last empty line, left by a final newline, is not shown.
+func bottomOrigin(lines [][]cell, maxY int) int {
+	n := len(lines)
+	if n > 0 && len(lines[n-1]) == 0 {
+		n--
+	}
+	return max(n-maxY, 0)
+}
+
+// ScrollUp moves the origin of the view up by amount lines, stopping at the
+// first line. In an Autoscroll view this pauses the automatic scrolling.
+func (v *View) ScrollUp(amount int) {
+	if v.Autoscroll && !v.autoscrollPaused {
+		_, maxY := v.Size()
+		v.oy = bottomOrigin(v.viewLines(), maxY)
+		v.autoscrollPaused = true
+	}
+	v.oy -= amount
+	if v.oy < 0 {
+		v.oy = 0
+	}
+	v.tainted = true
+}
+
+// ScrollDown moves the origin of the view down by amount lines, stopping
+// when the last line is at the bottom of the view. Reaching the bottom of an
+// Autoscroll view resumes the automatic scrolling.
+func (v *View) ScrollDown(amount int) {
+	_, maxY := v.Size()
+	maxOy := bottomOrigin(v.viewLines(), maxY)
+	if oy := v.oy + amount; oy <= maxOy {
+		v.oy = oy
+	} else if v.oy < maxOy {
+		v.oy = maxOy
+	}
+	if v.autoscrollPaused && v.oy >= maxOy {
+		v.autoscrollPaused = false
+	}
+	v.tainted = true
+}
+
+// scrollbarThumb returns the position and the size of the scrollbar thumb,
+// relative to the start of its track. ok is false if the content fits in the
+// view and there is nothing to scroll.
+func (v *View) scrollbarThumb(vertical bool) (pos, size int, ok bool) {
+	maxX, maxY := v.Size()
+	track, content, origin := maxX, v.contentWidth, v.ox
+	if vertical {
+		track, content, origin = maxY, v.contentHeight, v.oy
+	}
+	if track <= 0 || content <= track {
+		return 0, 0, false
+	}
+
+	size = track * track / content
+	if size < 1 {
+		size = 1
+	}
+	pos = (track - size) * origin / (content - track)
+	if pos > track-size {
+		pos = track - size
+	}
+	return pos, size, true
+}
+
+// scrollToThumb sets the origin of the view so that the scrollbar thumb
+// starts at the given position of its track.
+func (v *View) scrollToThumb(vertical bool, pos int) {
+	maxX, maxY := v.Size()
+	track, content := maxX, v.contentWidth
+	if vertical {
+		track, content = maxY, v.contentHeight
+	}
+	_, size, ok := v.scrollbarThumb(vertical)
+	if !ok {
+		return
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	if pos > track-size {
+		pos = track - size
+	}
+
+	// the smallest origin drawing the thumb at pos, rounding up
+	origin := content - track
+	if track > size {
+		origin = (pos*(content-track) + track - size - 1) / (track - size)
+	}
+	v.tainted = true
+	if !vertical {
+		v.ox = origin
+		return
+	}
+	v.oy = origin
+	if v.Autoscroll {
+		v.autoscrollPaused = origin < bottomOrigin(v.viewLines(), track)
+	}
+}
+
+// ScrollLeft moves the origin of the view left by amount columns. It has no
+// effect if Wrap is enabled.
+func (v *View) ScrollLeft(amount int) {
+	if v.Wrap {
+		return
+	}
+	v.ox -= amount
+	if v.ox < 0 {
+		v.ox = 0
+	}
+	v.tainted = true
+}
+
+// ScrollRight moves the origin of the view right by amount columns, stopping
+// when the end of the longest line is visible. It has no effect if Wrap is
+// enabled.
+func (v *View) ScrollRight(amount int) {
+	if v.Wrap {
+		return
+	}
+	maxX, _ := v.Size()
+	maxOx := 0
+	for _, line := range v.lines {
+		if w := lineWidth(line, v.tabWidth()) - maxX; w > maxOx {
+			maxOx = w
+		}
+	}
+	if ox := v.ox + amount; ox <= maxOx {
+		v.ox = ox
+	} else if v.ox < maxOx {
+		v.ox = maxOx
+	}
+	v.tainted = true
+}
+
 // SetWritePos sets the write position of the view's internal buffer.
 // So the next Write call would write directly to the specified position.
 func (v *View) SetWritePos(x, y int) error {
@@ -388,6 +723,7 @@ func (v *View) Write(p []byte) (n int, err error) {
 	defer v.writeMutex.Unlock()
 	v.makeWriteable(v.wx, v.wy)
 	v.writeRunes(bytes.Runes(p))
+	v.evictLines()
 
 	return len(p), nil
 }
@@ -398,6 +734,66 @@ func (v *View) WriteRunes(p []rune) {
 	// Fill with empty cells, if writing outside current view buffer
 	v.makeWriteable(v.wx, v.wy)
 	v.writeRunes(p)
+	v.evictLines()
+}
+
+// evictLines drops the oldest lines of the buffer above MaxLines. The lines
+// are a window sliding over the underlying array, which works as a ring
+// buffer: dropping lines doesn't move the other ones, and the array is
+// compacted when append has to grow it.
+func (v *View) evictLines() {
+	n := len(v.lines) - v.MaxLines
+	if v.MaxLines <= 0 || n <= 0 {
+		return
+	}
+
+	// in Wrap mode the origin and cursor are in wrapped lines
+	dropped := n
+	if v.Wrap {
+		dropped = 0
+		for _, line := range v.lines[:n] {
+			dropped += v.lineRows(line)
+		}
+	}
+
+	for i := range v.lines[:n] {
+		v.lines[i] = nil
+	}
+	v.lines = v.lines[n:]
+	v.version++
+	// the undo history refers to the dropped lines
+	v.ClearHistory()
+	v.shiftSigns(0, -n)
+
+	v.wy -= n
+	if v.wy < 0 {
+		v.wx, v.wy = 0, 0
+	}
+	v.ry -= n
+	if v.ry < 0 {
+		v.rx, v.ry = 0, 0
+	}
+	if sel := v.selection; sel != nil {
+		sel.start.y -= n
+		sel.end.y -= n
+		if sel.end.y < 0 {
+			v.selection = nil
+		} else if sel.start.y < 0 {
+			sel.start = bufferPos{}
+		}
+	}
+	if v.search != nil {
+		v.search.dropLines(n)
+	}
+	// the cursor follows its line if it is still there
+	v.cy -= n
+	if v.cy < 0 {
+		v.cx, v.cy = 0, 0
+	}
+	v.oy -= dropped
+	if v.oy < 0 {
+		v.oy = 0
+	}
 }
 
 func (v *View) WriteString(s string) {
@@ -407,7 +803,16 @@ func (v *View) WriteString(s string) {
 // writeRunes copies slice of runes into internal lines buffer.
 // caller must make sure that writing position is accessable.
 func (v *View) writeRunes(p []rune) {
+	v.version++
+	v.ClearHistory()
 	for _, r := range p {
+		if r == '\b' && v.ControlSequences {
+			if v.wx > 0 {
+				v.wx--
+			}
+			continue
+		}
+
 		switch r {
 		case '\n':
 			v.wy++
@@ -422,6 +827,12 @@ func (v *View) writeRunes(p []rune) {
 		default:
 			cells := v.parseInput(r)
 			if cells == nil {
+				if ins := v.ei.takeInstruction(); ins.kind != 0 && v.ControlSequences {
+					v.executeInstruction(ins)
+				}
+				continue
+			}
+			if len(cells) == 1 && v.joinCluster(v.wx, v.wy, r) {
 				continue
 			}
 			v.writeCells(v.wx, v.wy, cells)
@@ -430,6 +841,105 @@ func (v *View) writeRunes(p []rune) {
 	}
 }
 
+// executeInstruction moves the write position or erases parts of the buffer
+// according to a cursor control sequence. It leaves the write position
+// writeable.
+func (v *View) executeInstruction(ins instruction) {
+	if ins.esc || ins.private != 0 {
+		return
+	}
+
+	switch ins.kind {
+	case 'A':
+		v.wy -= ins.param(0, 1)
+	case 'B':
+		v.wy += ins.param(0, 1)
+	case 'C':
+		v.wx += ins.param(0, 1)
+	case 'D':
+		v.wx -= ins.param(0, 1)
+	case 'E':
+		v.wy += ins.param(0, 1)
+		v.wx = 0
+	case 'F':
+		v.wy -= ins.param(0, 1)
+		v.wx = 0
+	case 'G':
+		v.wx = ins.param(0, 1) - 1
+	case 'H', 'f':
+		v.wy = ins.param(0, 1) - 1
+		v.wx = ins.param(1, 1) - 1
+	case 'J':
+		v.eraseInDisplay(ins.param(0, 0))
+	case 'K':
+		v.eraseInLine(ins.param(0, 0))
+	}
+
+	// the moves stop at the end of the buffer or of the view, whichever is
+	// further, so that a sequence can't make the buffer grow without bounds
+	maxX, maxY := v.Size()
+	v.wy = max(min(v.wy, max(len(v.lines), maxY-1)), 0)
+	lineLen := 0
+	if v.wy < len(v.lines) {
+		lineLen = len(v.lines[v.wy])
+	}
+	v.wx = max(min(v.wx, max(lineLen, maxX-1)), 0)
+	v.makeWriteable(v.wx, v.wy)
+}
+
+// eraseInLine erases the line at the write position: from the write position
+// to the end of the line (mode 0), from the start of the line to the write
+// position (mode 1) or the whole line (mode 2).
+func (v *View) eraseInLine(mode int) {
+	if v.wy >= len(v.lines) {
+		return
+	}
+	line := v.lines[v.wy]
+	// truncated cells are cleared, as makeWriteable reuses the capacity of
+	// the line
+	truncate := func(x int) {
+		for i := x; i < len(line); i++ {
+			line[i] = cell{}
+		}
+		v.lines[v.wy] = line[:x]
+	}
+	switch mode {
+	case 0:
+		if v.wx < len(line) {
+			truncate(v.wx)
+		}
+	case 1:
+		for x := 0; x <= v.wx && x < len(line); x++ {
+			line[x] = cell{chr: ' ', fgColor: v.ei.curFgColor, bgColor: v.ei.curBgColor}
+		}
+	case 2:
+		truncate(0)
+	}
+}
+
+// eraseInDisplay erases the buffer from the write position to the end
+// (mode 0), from the start to the write position (mode 1) or entirely (modes
+// 2 and 3). The write position is not changed.
+func (v *View) eraseInDisplay(mode int) {
+	switch mode {
+	case 0:
+		v.eraseInLine(0)
+		if v.wy+1 < len(v.lines) {
+			for y := v.wy + 1; y < len(v.lines); y++ {
+				v.lines[y] = nil
+			}
+			v.lines = v.lines[:v.wy+1]
+		}
+	case 1:
+		for y := 0; y < v.wy && y < len(v.lines); y++ {
+			v.lines[y] = nil
+		}
+		v.eraseInLine(1)
+	case 2, 3:
+		v.lines = nil
+	}
+}
+
 // parseInput parses char by char the input written to the View. It returns nil
 // while processing ESC sequences. Otherwise, it returns a cell slice that
 // contains the processed data.
@@ -451,19 +961,12 @@ func (v *View) parseInput(ch rune) []cell {
 		if isEscape {
 			return nil
 		}
-		repeatCount := 1
-		if ch == '\t' {
-			ch = ' '
-			repeatCount = 4
-		}
-		for i := 0; i < repeatCount; i++ {
-			c := cell{
-				fgColor: v.ei.curFgColor,
-				bgColor: v.ei.curBgColor,
-				chr:     ch,
-			}
-			cells = append(cells, c)
+		c := cell{
+			fgColor: v.ei.curFgColor,
+			bgColor: v.ei.curBgColor,
+			chr:     ch,
 		}
+		cells = append(cells, c)
 	}
 
 	return cells
@@ -487,13 +990,18 @@ func (v *View) Read(p []byte) (n int, err error) {
 	}
 	for v.ry < len(v.lines) {
 		for v.rx < len(v.lines[v.ry]) {
-			count := utf8.EncodeRune(buffer, v.lines[v.ry][v.rx].chr)
-			copy(p[offset:], buffer[:count])
+			c := v.lines[v.ry][v.rx]
+			buffer = buffer[:0]
+			for _, r := range c.runes() {
+				buffer = append(buffer, string(r)...)
+			}
+			count := len(buffer)
+			copy(p[offset:], buffer)
 			v.rx++
 			newOffset := offset + count
 			if newOffset >= len(p) {
 				if newOffset > len(p) {
-					v.readBuffer = buffer[newOffset-len(p):]
+					v.readBuffer = buffer[count-(newOffset-len(p)):]
 				}
 				return len(p), nil
 			}
@@ -521,14 +1029,37 @@ func (v *View) Rewind() {
 
 // viewLines returns the lines to render on the screen
 func (v *View) viewLines() [][]cell {
+	return v.wrapLines(v.lines)
+}
+
+// lineRows returns the number of lines of the view showing a buffer line,
+// which is more than one when it is wrapped.
+func (v *View) lineRows(line []cell) int {
 	if !v.Wrap {
-		return v.lines
+		return 1
+	}
+	rows := 1
+	for continuation := false; ; continuation = true {
+		if _, _, end := v.takeLine(&line, continuation); end {
+			return rows
+		}
+		rows++
+	}
+}
+
+// wrapLines returns the given buffer lines wrapped in Wrap mode.
+func (v *View) wrapLines(lines [][]cell) [][]cell {
+	if !v.Wrap {
+		return lines
 	}
 
 	renderLines := [][]cell{}
-	for _, viewLine := range v.lines {
-		for {
-			lineToRender, _, end := v.takeLine(&viewLine)
+	for _, viewLine := range lines {
+		for continuation := false; ; continuation = true {
+			lineToRender, _, end := v.takeLine(&viewLine, continuation)
+			if continuation {
+				lineToRender = append(v.wrapPrefix(), lineToRender...)
+			}
 			renderLines = append(renderLines, lineToRender)
 			if end {
 				break
@@ -549,7 +1080,8 @@ func (v *View) draw() error {
 		return nil
 	}
 
-	maxX, maxY := v.Size()
+	v.drawnGutterWidth = v.gutterWidth()
+	maxX, maxY := v.x1-v.x0-1-v.drawnGutterWidth, v.y1-v.y0-1
 
 	if v.Wrap {
 		if maxX == 0 {
@@ -561,21 +1093,45 @@ func (v *View) draw() error {
 	}
 
 	if !v.tainted && v.contentCache != nil {
+		v.updateHighlightY()
 		for _, cell := range v.contentCache {
-			if err := v.setRune(cell.x, cell.y, cell.chr, cell.fgColor, cell.bgColor); err != nil {
+			if err := v.setRune(cell.x, cell.y, cell.chr, cell.comb, cell.fgColor, cell.bgColor); err != nil {
 				return err
 			}
 		}
+		v.drawGutter()
 		return nil
 	}
 
-	linesToRender := v.viewLines()
+	lines := v.lines
+	if v.Styler != nil {
+		lines = v.styleLines(lines, maxY)
+	}
+	if v.search != nil {
+		v.updateMatches()
+		lines = v.highlightMatches(lines)
+	}
+	if v.selection != nil {
+		lines = v.highlightSelection(lines)
+	}
+	linesToRender := v.wrapLines(lines)
+	v.contentHeight = len(linesToRender)
+	if v.HorizontalScrollbar {
+		v.contentWidth = 0
+		for _, line := range linesToRender {
+			if w := lineWidth(line, v.tabWidth()); w > v.contentWidth {
+				v.contentWidth = w
+			}
+		}
+	}
 
-	if v.Autoscroll && len(linesToRender) > maxY {
-		v.oy = len(linesToRender) - maxY - 1
+	if v.Autoscroll && !v.autoscrollPaused && len(linesToRender) > maxY {
+		v.oy = bottomOrigin(linesToRender, maxY)
 	}
+	v.updateHighlightY()
 
 	newCache := []cellCache{}
+	tabWidth := v.tabWidth()
 	y := 0
 	for lineIndex, line := range linesToRender {
 		if lineIndex < v.oy {
@@ -585,44 +1141,73 @@ func (v *View) draw() error {
 			break // No need to render out of screen chars
 		}
 
-		x := 0
-		for charIndex, char := range line {
-			if charIndex < v.ox {
-				continue
+		// x is the column on the screen, col the column in the line, the
+		// origin is a column too
+		x, col := 0, 0
+		for _, char := range line {
+			w := cellWidthAt(char, col, tabWidth)
+			cells := []cell{char}
+			if char.chr == '\t' {
+				// tabs are drawn as spaces up to the next tab stop
+				cells = make([]cell, w)
+				for i := range cells {
+					cells[i] = cell{chr: ' ', fgColor: char.fgColor, bgColor: char.bgColor}
+				}
+				if v.TabMarker != 0 {
+					cells[0].chr = v.TabMarker
+				}
 			}
-			if x >= maxX {
-				break // No need to render out of screen chars
+			if col < v.ox {
+				col += w
+				if char.chr != '\t' || col <= v.ox {
+					// a wide character cut by the origin is not drawn
+					x = max(col-v.ox, 0)
+					continue
+				}
+				// the rest of a tab cut by the origin is drawn
+				cells = cells[len(cells)-(col-v.ox):]
+			} else {
+				col += w
 			}
 
-			fgColor := char.fgColor
-			if fgColor == ColorDefault {
-				fgColor = v.FgColor
-			}
-			bgColor := char.bgColor
-			if bgColor == ColorDefault {
-				bgColor = v.BgColor
-			}
+			for _, char := range cells {
+				if x >= maxX {
+					break // No need to render out of screen chars
+				}
 
-			newCache = append(newCache, cellCache{
-				chr:     char.chr,
-				bgColor: bgColor,
-				fgColor: fgColor,
-				x:       x,
-				y:       y,
-			})
-			if err := v.setRune(x, y, char.chr, fgColor, bgColor); err != nil {
-				return err
+				// cells without a color, but maybe with text attributes,
+				// use the colors of the view
+				fgColor := char.fgColor
+				if fgColor&AttrColorBits == ColorDefault {
+					fgColor |= v.FgColor
+				}
+				bgColor := char.bgColor
+				if bgColor&AttrColorBits == ColorDefault {
+					bgColor |= v.BgColor
+				}
+
+				newCache = append(newCache, cellCache{
+					chr:     char.chr,
+					comb:    char.comb,
+					bgColor: bgColor,
+					fgColor: fgColor,
+					x:       x,
+					y:       y,
+				})
+				if err := v.setRune(x, y, char.chr, char.comb, fgColor, bgColor); err != nil {
+					return err
+				}
+				x += cellWidth(char)
 			}
-			if char.chr == 0 {
-				x++ // if NULL increase, so `SetWritePos` can be used (NULL translate to SPACE in setRune)
-			} else {
-				x += runewidth.RuneWidth(char.chr)
+			if x >= maxX {
+				break // No need to render out of screen chars
 			}
 		}
 		y++
 	}
 
 	v.contentCache = newCache
+	v.drawGutter()
 	return nil
 }
 
@@ -634,11 +1219,28 @@ func (v *View) Clear() {
 	v.tainted = true
 	v.ei.reset()
 	v.lines = [][]cell{}
+	v.version++
+	v.ClearHistory()
+	v.selection = nil
+	v.signs = nil
 	v.SetCursor(0, 0)
 	v.SetOrigin(0, 0)
+	v.autoscrollPaused = false
 	v.clearRunes()
 }
 
+// updateHighlightY sets the line of the view highlighted when Highlight is
+// set, from the current origin.
+func (v *View) updateHighlightY() {
+	if !v.Editable {
+		// the cursor of other views is relative to the origin
+		v.highlightY = v.cy
+		return
+	}
+	_, y, _ := v.linesPosOnScreen(v.cx, v.cy)
+	v.highlightY = y - v.oy
+}
+
 // linesPosOnScreen returns based on the view lines the x and y location
 // the viewX and viewY are NOT based on the view offsets
 // isOnScreen is false if the selected corodinates is not on screen (this is based on the view offsets)
@@ -649,7 +1251,7 @@ func (v *View) linesPosOnScreen(x, y int) (viewX int, viewY int, visable bool) {
 
 	maxX, maxY := v.Size()
 	if !v.Wrap {
-		viewX = x
+		viewX = v.BufferXToColumn(x, y)
 		viewY = y
 		visable = viewY >= v.oy && viewY < v.oy+maxY && viewX >= v.ox && viewX < v.ox+maxX
 		return
@@ -665,8 +1267,8 @@ func (v *View) linesPosOnScreen(x, y int) (viewX int, viewY int, visable bool) {
 			break
 		}
 
-		for {
-			_, _, end := v.takeLine(&viewLine)
+		for continuation := false; ; continuation = true {
+			_, _, end := v.takeLine(&viewLine, continuation)
 			viewY++
 			if end {
 				break
@@ -675,18 +1277,22 @@ func (v *View) linesPosOnScreen(x, y int) (viewX int, viewY int, visable bool) {
 	}
 
 	if found {
-		for {
-			lineChars, width, end := v.takeLine(&line)
+		for continuation := false; ; continuation = true {
+			lineChars, width, end := v.takeLine(&line, continuation)
+			indent := 0
+			if continuation {
+				indent = v.wrapPrefixWidth()
+			}
 			lenLineChars := len(lineChars)
 			if x < lenLineChars {
-				x = lineWidth(lineChars[:x])
+				x = columnAfter(lineChars[:x], indent, v.tabWidth())
 				break
 			} else {
 				x -= lenLineChars
 			}
 
 			if end {
-				x += width
+				x += indent + width
 				break
 			}
 			viewY++
@@ -708,10 +1314,11 @@ func (v *View) linesPosOnScreen(x, y int) (viewX int, viewY int, visable bool) {
 
 // clearRunes erases all the cells in the view.
 func (v *View) clearRunes() {
-	maxX, maxY := v.Size()
+	// the gutter is cleared too
+	maxX, maxY := v.x1-v.x0-1, v.y1-v.y0-1
 	for x := 0; x < maxX; x++ {
 		for y := 0; y < maxY; y++ {
-			tcellSetCell(v.x0+x+1, v.y0+y+1, ' ', v.FgColor, v.BgColor, v.outMode)
+			tcellSetCell(v.x0+x+1, v.y0+y+1, ' ', nil, v.FgColor, v.BgColor, v.outMode)
 		}
 	}
 }
@@ -801,10 +1408,10 @@ func (v *View) Word(x, y int) (string, error) {
 	return string(str[nl:nr]), nil
 }
 
-// indexFunc allows to split lines by words taking into account spaces
-// and 0.
+// indexFunc allows to split lines by words taking into account spaces,
+// tabs and 0.
 func indexFunc(r rune) bool {
-	return r == ' ' || r == 0
+	return r == ' ' || r == '\t' || r == 0
 }
 
 // SetLine changes the contents of an existing line.
@@ -818,9 +1425,15 @@ func (v *View) SetLine(y int, text string) error {
 	line := make([]cell, 0)
 	for _, r := range text {
 		c := v.parseInput(r)
+		if n := len(line); n > 0 && len(c) == 1 && line[n-1].extendedBy(r) {
+			line[n-1] = line[n-1].withRune(r)
+			continue
+		}
 		line = append(line, c...)
 	}
-	v.lines[y] = line
+	v.edit(editOther, y, y, func() {
+		v.lines[y] = line
+	})
 	return nil
 }
 
@@ -849,20 +1462,26 @@ func (v *View) SetHighlight(y int, on bool) error {
 	return nil
 }
 
-func lineWidth(line []cell) (n int) {
-	for i := range line {
-		if line[i].chr == 0 {
-			n++ // if it's NULL character, it's translated to SPACE in setRune
-		} else {
-			n += runewidth.RuneWidth(line[i].chr)
-		}
-	}
+// lineWidth returns the number of columns used to draw a line, with tab
+// stops every tabWidth columns.
+func lineWidth(line []cell, tabWidth int) int {
+	return columnAfter(line, 0, tabWidth)
+}
 
-	return
+// columnAfter returns the column after the cells of line drawn from the
+// column start, with tab stops every tabWidth columns.
+func columnAfter(line []cell, start, tabWidth int) int {
+	col := start
+	for _, c := range line {
+		col += cellWidthAt(c, col, tabWidth)
+	}
+	return col
 }
 
-// takeLine slices one visable line from l and returns the sliced part
-func (v *View) takeLine(l *[]cell) (visableLine []cell, width int, end bool) {
+// takeLine slices one visable line from l and returns the sliced part.
+// continuation is true when l is the rest of a line which was already
+// wrapped, in which case the line is shortened by the wrap prefix.
+func (v *View) takeLine(l *[]cell, continuation bool) (visableLine []cell, width int, end bool) {
 	if l == nil {
 		panic("take line l can't be nil")
 	}
@@ -876,35 +1495,136 @@ func (v *View) takeLine(l *[]cell) (visableLine []cell, width int, end bool) {
 	}
 
 	maxX, _ := v.Size()
-	i := 0
-	cell := cell{}
-
-	for i, cell = range *l {
-		chr := cell.chr
-		charWidth := 1 // default for NULL character (translated to SPACE in setRune)
-		if chr != 0 {
-			charWidth = runewidth.RuneWidth(chr)
-		}
+	// tab stops are counted from the beginning of the line on the screen,
+	// continuation lines begin after the wrap prefix
+	start := 0
+	if continuation {
+		start = v.wrapPrefixWidth()
+	}
 
-		if width+charWidth > maxX {
-			i-- // decrease as this character is not included
+	// number of cells which fit in the line
+	n := 0
+	for n < len(*l) {
+		charWidth := cellWidthAt((*l)[n], start+width, v.tabWidth())
+		if start+width+charWidth > maxX {
 			break
 		}
-
 		width += charWidth
-		visableLine = append(visableLine, cell)
-		if width == maxX {
-			break
+		n++
+	}
+	if n == 0 {
+		// a character wider than the view, it is cut when drawn
+		n = 1
+	}
+
+	if v.WordWrap && n < len(*l) {
+		if b := wordBreak(*l, n); b > 0 {
+			n = b
+		}
+		// the spaces at the break hang past the end of the line
+		for n < len(*l) && ((*l)[n].chr == ' ' || (*l)[n].chr == '\t') {
+			n++
 		}
 	}
 
-	i++
-	end = i == len(*l)
-	*l = (*l)[i:]
+	visableLine = append(visableLine, (*l)[:n]...)
+	width = columnAfter(visableLine, start, v.tabWidth()) - start
+	end = n == len(*l)
+	*l = (*l)[n:]
 
 	return
 }
 
+// wrapPrefixWidth returns the width of the indentation of continuation lines
+// in Wrap mode, or 0 if it doesn't leave room for any text.
+func (v *View) wrapPrefixWidth() int {
+	maxX, _ := v.Size()
+	w := max(v.WrapIndent, runewidth.StringWidth(v.WrapMarker))
+	if w >= maxX {
+		return 0
+	}
+	return w
+}
+
+// wrapPrefix returns the cells drawn at the beginning of continuation lines
+// in Wrap mode: the WrapMarker, padded to WrapIndent.
+func (v *View) wrapPrefix() []cell {
+	w := v.wrapPrefixWidth()
+	if w == 0 {
+		return nil
+	}
+	prefix := make([]cell, 0, w)
+	width := 0
+	for _, ch := range v.WrapMarker {
+		prefix = append(prefix, cell{chr: ch})
+		width += runewidth.RuneWidth(ch)
+	}
+	for ; width < w; width++ {
+		prefix = append(prefix, cell{chr: ' '})
+	}
+	return prefix
+}
+
+// cellWidth returns the number of columns used to draw a cell, other than a
+// tab, see cellWidthAt.
+func cellWidth(c cell) int {
+	if c.chr == 0 {
+		return 1 // NULL character is translated to SPACE in setRune
+	}
+	return clusterWidth(c.chr, c.comb)
+}
+
+// cellWidthAt returns the number of columns used to draw a cell at the
+// column col: a tab extends to the next tab stop, with tab stops every
+// tabWidth columns.
+func cellWidthAt(c cell, col, tabWidth int) int {
+	if c.chr == '\t' {
+		return tabWidth - col%tabWidth
+	}
+	return cellWidth(c)
+}
+
+// tabWidth returns the number of columns between the tab stops of the view.
+func (v *View) tabWidth() int {
+	if v.TabWidth > 0 {
+		return v.TabWidth
+	}
+	return defaultTabWidth
+}
+
+// wordBreak returns the last position, from 1 to n, at which line can be
+// broken, or 0 if there is none. Line breaks are allowed after spaces, after
+// hyphens following a letter, after zero width spaces, and around wide
+// characters except before closing and after opening punctuation.
+func wordBreak(line []cell, n int) int {
+	if line[n].chr == ' ' || line[n].chr == '\t' {
+		return n
+	}
+	for b := n; b > 0; b-- {
+		prev, next := line[b-1].chr, line[b].chr
+		switch {
+		case next == ' ', next == '\t':
+			continue
+		case prev == ' ', prev == '\t', prev == '\u200b':
+			return b
+		case prev == '-' && b >= 2 && unicode.IsLetter(line[b-2].chr):
+			return b
+		case strings.ContainsRune(noBreakBefore, next), strings.ContainsRune(noBreakAfter, prev):
+			continue
+		case runewidth.RuneWidth(prev) == 2, runewidth.RuneWidth(next) == 2:
+			return b
+		}
+	}
+	return 0
+}
+
+// noBreakBefore and noBreakAfter are the punctuation marks which don't start
+// and don't end a line around wide characters.
+const (
+	noBreakBefore = ")]}、。，．：；！？）」』】〉》〕"
+	noBreakAfter  = "([{（「『【〈《〔"
+)
+
 func linesToString(lines [][]cell) string {
 	str := make([]string, len(lines))
 	for i := range lines {
@@ -0,0 +1,690 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// newTestView returns a view which doesn't need a running Gui.
+func newTestView(width, height int) *View {
+	g := &Gui{}
+	return g.newView("test", 0, 0, width+1, height+1, OutputNormal)
+}
+
+func TestWriteStyled(t *testing.T) {
+	v := newTestView(20, 5)
+	v.Print(Style{Fg: ColorRed | AttrBold}, "ERROR ")
+	v.WriteSegments(Segments{}.
+		Add(Style{}, "disk ").
+		Add(Style{Bg: ColorBlue}, "full\nnext")...)
+
+	if got := v.Buffer(); got != "ERROR disk full\nnext" {
+		t.Fatalf("unexpected buffer: %q", got)
+	}
+
+	tests := []struct {
+		x, y   int
+		fg, bg Attribute
+	}{
+		{0, 0, ColorRed | AttrBold, ColorDefault},
+		{6, 0, ColorDefault, ColorDefault},
+		{11, 0, ColorDefault, ColorBlue},
+		{0, 1, ColorDefault, ColorBlue},
+	}
+	for _, tt := range tests {
+		c := v.lines[tt.y][tt.x]
+		if c.fgColor != tt.fg || c.bgColor != tt.bg {
+			t.Errorf("cell (%d, %d): got fg %x bg %x, want fg %x bg %x", tt.x, tt.y, c.fgColor, c.bgColor, tt.fg, tt.bg)
+		}
+	}
+}
+
+func TestControlSequences(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{"carriage return and erase line", "downloading 10%\r\x1b[Kdone\n", "done\n"},
+		{"cursor up", "layer1: 0%\nlayer2: 0%\n\x1b[2A\x1b[2Klayer1: 100%\n\x1b[1B", "layer1: 100%\nlayer2: 0%\n"},
+		{"cursor column", "[    ]\x1b[2G##", "[##  ]"},
+		{"backspace", "ab\b\bxy", "xy"},
+		{"erase display", "a\nb\nc\x1b[2;1H\x1b[J", "a\n"},
+		{"position after erase", "abcdef\r\x1b[K\x1b[4Gx", "   x"},
+		{"bounded moves", "a\x1b[99999999B\x1b[1;99999999Hx", "a                  x\n\n\n\n "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestView(20, 5)
+			v.ControlSequences = true
+			if _, err := v.Write([]byte(tt.input)); err != nil {
+				t.Fatal(err)
+			}
+			if got := v.Buffer(); got != tt.output {
+				t.Errorf("got %q, want %q", got, tt.output)
+			}
+		})
+	}
+}
+
+func TestViewTheme(t *testing.T) {
+	v := newTestView(10, 2)
+	theme, err := ParseTheme([]byte(`{
+		"text": {"fg": "white", "bg": "16"},
+		"frame": {"fg": "#00ff00", "attrs": ["bold"]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v.SetRoleStyle(RoleFrame, Style{Fg: ColorRed})
+	v.gui.views = append(v.gui.views, v)
+	v.gui.SetTheme(theme)
+
+	if v.FgColor != GetColor("white") || v.BgColor != Get256Color(16) {
+		t.Errorf("got text colors %v/%v", v.FgColor, v.BgColor)
+	}
+	if v.FrameColor != ColorRed {
+		t.Errorf("got frame color %v, want the overridden %v", v.FrameColor, ColorRed)
+	}
+	if v.gui.FrameColor != GetColor("#00ff00")|AttrBold {
+		t.Errorf("got gui frame color %v", v.gui.FrameColor)
+	}
+
+	v.ResetRoleStyle(RoleFrame)
+	if v.FrameColor != v.gui.FrameColor {
+		t.Errorf("got frame color %v after reset, want %v", v.FrameColor, v.gui.FrameColor)
+	}
+
+	// removing the theme restores the default colors
+	v.gui.SetTheme(Theme{RoleMatch: {Fg: ColorGreen}})
+	v.gui.SetTheme(nil)
+	if v.FgColor != ColorDefault || v.BgColor != ColorDefault || v.MatchStyle != (Style{Fg: AttrReverse}) {
+		t.Errorf("got colors %v/%v and match style %v without a theme", v.FgColor, v.BgColor, v.MatchStyle)
+	}
+
+	if _, err := ParseTheme([]byte(`{"text": {"fg": "nocolor"}}`)); err == nil {
+		t.Error("expected an error for an invalid color")
+	}
+}
+
+func TestViewMaxLines(t *testing.T) {
+	v := newTestView(10, 2)
+	v.MaxLines = 3
+	fmt.Fprint(v, "line 0\nline 1\nline 2")
+	// the cursor is on "line 2", the origin on "line 1"
+	v.SetOrigin(0, 1)
+	v.SetCursor(0, 2)
+
+	fmt.Fprint(v, "\nline 3\nline 4")
+	if got, want := v.BufferLines(), []string{"line 2", "line 3", "line 4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got buffer %q, want %q", got, want)
+	}
+	if _, oy := v.Origin(); oy != 0 {
+		t.Errorf("got origin %d, want 0", oy)
+	}
+	if _, cy := v.Cursor(); cy != 0 {
+		t.Errorf("got cursor %d, want 0", cy)
+	}
+
+	fmt.Fprint(v, "\nline 5")
+	if got, want := v.BufferLines(), []string{"line 3", "line 4", "line 5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got buffer %q, want %q", got, want)
+	}
+
+	// the origin, the cursor and the search matches stay on their lines
+	v = newTestView(10, 2)
+	v.MaxLines = 10
+	for i := 0; i < 9; i++ {
+		fmt.Fprintf(v, "line %d\n", i)
+	}
+	fmt.Fprint(v, "line 9")
+	if err := v.Search("line 8", SearchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	v.SetOrigin(0, 5)
+	v.SetCursor(0, 7)
+	fmt.Fprint(v, "\nline 10\nline 11\nline 12")
+	if _, oy := v.Origin(); oy != 2 {
+		t.Errorf("got origin %d, want 2", oy)
+	}
+	if _, cy := v.Cursor(); cy != 4 {
+		t.Errorf("got cursor %d, want 4", cy)
+	} else if line, _ := v.Line(cy); line != "line 7" {
+		t.Errorf("got cursor on %q, want line 7", line)
+	}
+	if m := v.search.matches[v.search.current]; m.y != 5 {
+		t.Errorf("got current match on line %d, want 5", m.y)
+	}
+}
+
+func TestWordWrap(t *testing.T) {
+	v := newTestView(10, 5)
+	v.Wrap = true
+	v.WordWrap = true
+	v.WrapIndent = 2
+	v.WrapMarker = "↪"
+	fmt.Fprint(v, "the quick brown fox\nan_overlong_word")
+
+	var got []string
+	for _, line := range v.viewLines() {
+		got = append(got, lineType(line).String())
+	}
+	want := []string{"the quick ", "↪ brown ", "↪ fox", "an_overlon", "↪ g_word"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got lines %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		x, y, wantX, wantY int
+	}{
+		{4, 0, 4, 0},
+		{16, 0, 2, 2},
+		{19, 0, 5, 2},
+		{12, 1, 4, 4},
+	}
+	for _, test := range tests {
+		x, y, _ := v.linesPosOnScreen(test.x, test.y)
+		if x != test.wantX || y != test.wantY {
+			t.Errorf("position %d,%d: got %d,%d on screen, want %d,%d", test.x, test.y, x, y, test.wantX, test.wantY)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	v := newTestView(10, 2)
+	fmt.Fprint(v, "foo bar\nbaz\nFOO foo\nx\nfoo")
+
+	if err := v.Search("foo", SearchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if n, i := v.MatchCount(), v.MatchIndex(); n != 3 || i != 0 {
+		t.Errorf("got match %d of %d, want 0 of 3", i, n)
+	}
+
+	for _, wantOy := range []int{1, 3, 0} {
+		if !v.NextMatch() {
+			t.Fatal("NextMatch returned false")
+		}
+		if _, oy := v.Origin(); oy != wantOy {
+			t.Errorf("match %d: got origin %d, want %d", v.MatchIndex(), oy, wantOy)
+		}
+	}
+	v.PrevMatch()
+	if i := v.MatchIndex(); i != 2 {
+		t.Errorf("got match %d after PrevMatch, want 2", i)
+	}
+
+	lines := v.highlightMatches(v.lines)
+	if c := lines[4][0]; c.fgColor != v.CurrentMatchStyle.Fg || c.bgColor != v.CurrentMatchStyle.Bg {
+		t.Errorf("current match not highlighted: %+v", c)
+	}
+	if c := lines[2][4]; c.fgColor != AttrReverse {
+		t.Errorf("match not highlighted: %+v", c)
+	}
+	if c := v.lines[2][4]; c.fgColor != ColorDefault {
+		t.Errorf("buffer modified by the highlighting: %+v", c)
+	}
+
+	if err := v.Search("foo", SearchOptions{IgnoreCase: true}); err != nil {
+		t.Fatal(err)
+	}
+	if n := v.MatchCount(); n != 4 {
+		t.Errorf("got %d case-insensitive matches, want 4", n)
+	}
+	if err := v.Search("ba[rz]", SearchOptions{Regexp: true}); err != nil {
+		t.Fatal(err)
+	}
+	if n := v.MatchCount(); n != 2 {
+		t.Errorf("got %d regexp matches, want 2", n)
+	}
+	if err := v.Search("(", SearchOptions{Regexp: true}); err == nil {
+		t.Error("expected an error for an invalid regexp")
+	}
+
+	// the buffer is only searched again when it changes
+	v.search.matches = v.search.matches[:1]
+	if n := v.MatchCount(); n != 1 {
+		t.Errorf("got %d matches, want the 1 cached one", n)
+	}
+	fmt.Fprint(v, " baz")
+	if n := v.MatchCount(); n != 3 {
+		t.Errorf("got %d matches after a write, want 3", n)
+	}
+}
+
+func TestTextSelection(t *testing.T) {
+	v := newTestView(20, 3)
+	v.Selectable = true
+	g := v.gui
+	g.views = append(g.views, v)
+	fmt.Fprint(v, "hello big world\nsecond line\nthird")
+
+	// the inner area of the view starts at 1, 1
+	g.startTextSelect(1+6, 1, 1)
+	if v.HasSelection() {
+		t.Error("a click shouldn't select anything")
+	}
+	g.updateTextSelect(1+2, 2)
+	g.endTextSelect()
+	if got, want := v.SelectedText(), "big world\nsec"; got != want {
+		t.Errorf("got selection %q, want %q", got, want)
+	}
+
+	g.startTextSelect(1+12, 1, 2)
+	g.endTextSelect()
+	if got, want := v.SelectedText(), "world"; got != want {
+		t.Errorf("got double-click selection %q, want %q", got, want)
+	}
+
+	g.startTextSelect(1+3, 2, 3)
+	g.updateTextSelect(1+3, 3)
+	g.endTextSelect()
+	if got, want := v.SelectedText(), "second line\nthird"; got != want {
+		t.Errorf("got triple-click selection %q, want %q", got, want)
+	}
+
+	g.startTextSelect(1, 1, 1)
+	g.endTextSelect()
+	if v.HasSelection() {
+		t.Error("a click should clear the selection")
+	}
+
+	g.startTextSelect(1, 1, 1)
+	g.updateTextSelect(1+4, 1)
+	g.endTextSelect()
+	v.Editable = true
+	v.SetCursor(0, 0)
+	v.EditWrite('>')
+	if v.HasSelection() {
+		t.Error("an edit should clear the selection")
+	}
+
+	var copied []string
+	g.ClipboardWriter = func(text string) error {
+		copied = append(copied, text)
+		return nil
+	}
+	g.CopyToClipboard("one")
+	g.CopyToClipboard("two")
+	if len(copied) != 0 {
+		t.Error("the clipboard should be written after the flush")
+	}
+	if err := g.flushClipboard(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(copied, []string{"one", "two"}) {
+		t.Errorf("got copied texts %q, want one and two", copied)
+	}
+
+	var buf bytes.Buffer
+	if err := writeOSC52(&buf, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "\x1b]52;c;aGk=\x07"; got != want {
+		t.Errorf("got OSC 52 sequence %q, want %q", got, want)
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	v := newTestView(20, 3)
+	v.Editable = true
+	for _, ch := range "hello" {
+		v.EditWrite(ch)
+	}
+	v.EditNewLine()
+	for _, ch := range "world" {
+		v.EditWrite(ch)
+	}
+	v.EditDelete(true)
+	v.EditDelete(true)
+
+	steps := []string{"hello\nwor", "hello\nworld", "hello\n", "hello", ""}
+	for _, want := range steps[1:] {
+		if !v.Undo() {
+			t.Fatal("Undo returned false")
+		}
+		if got := v.Buffer(); got != want {
+			t.Errorf("got %q after undo, want %q", got, want)
+		}
+	}
+	if v.Undo() {
+		t.Error("Undo returned true with an empty history")
+	}
+
+	v.Redo()
+	v.Redo()
+	if got, want := v.Buffer(), "hello\n"; got != want {
+		t.Errorf("got %q after redo, want %q", got, want)
+	}
+	if x, y := v.Cursor(); x != 0 || y != 1 {
+		t.Errorf("got cursor %d,%d after redo, want 0,1", x, y)
+	}
+
+	if err := v.SetLine(0, "bye"); err != nil {
+		t.Fatal(err)
+	}
+	if v.Redo() {
+		t.Error("Redo returned true after a new edit")
+	}
+	v.Undo()
+	if got, want := v.Buffer(), "hello\n"; got != want {
+		t.Errorf("got %q after undoing SetLine, want %q", got, want)
+	}
+
+	// edits which change nothing don't clear the redo history
+	v.SetCursor(0, 0)
+	v.EditDelete(true)
+	if !v.Redo() {
+		t.Error("Redo returned false after an edit without effect")
+	}
+
+	// only the changed lines are saved
+	v = newTestView(20, 3)
+	v.Editable = true
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(v, "line %d\n", i)
+	}
+	v.SetCursor(4, 500)
+	for _, ch := range "abc" {
+		v.EditWrite(ch)
+	}
+	v.EditNewLine()
+	if n := len(v.history.undo); n != 2 {
+		t.Fatalf("got %d undo steps, want 2", n)
+	}
+	for _, step := range v.history.undo {
+		for _, c := range step.changes {
+			if len(c.old) > 1 || len(c.new) > 2 {
+				t.Errorf("got change of %d lines to %d lines, want only the changed ones", len(c.old), len(c.new))
+			}
+		}
+	}
+	v.Undo()
+	v.Undo()
+	if line, _ := v.Line(500); line != "line 500" || v.LinesHeight() != 1001 {
+		t.Errorf("got line %q after undo", line)
+	}
+
+	// writes replace the history, which refers to the old content
+	v = newTestView(20, 3)
+	v.Editable = true
+	typeKeys(DefaultEditor, v, "abc")
+	v.Rewind()
+	fmt.Fprint(v, "new")
+	if v.Undo() || v.Buffer() != "new" {
+		t.Errorf("got buffer %q after undoing an edit older than a write", v.Buffer())
+	}
+}
+
+func TestGraphemeClusters(t *testing.T) {
+	tests := []struct {
+		text  string
+		cells int
+		width int
+	}{
+		{"e\u0301te\u0301", 3, 3},
+		{"\U0001F1EB\U0001F1F7!", 2, 3},
+		{"\U0001F469\u200d\U0001F469\u200d\U0001F467", 1, 2},
+		{"\u2764\ufe0f", 1, 2},
+		{"\U0001F44D\U0001F3FD", 1, 2},
+	}
+	for _, test := range tests {
+		v := newTestView(20, 2)
+		fmt.Fprint(v, test.text)
+		v.WriteStyled(test.text, ColorDefault, ColorDefault)
+		if got := v.Buffer(); got != test.text+test.text {
+			t.Errorf("%q: got buffer %q", test.text, got)
+		}
+		if got := len(v.lines[0]); got != 2*test.cells {
+			t.Errorf("%q: got %d cells, want %d", test.text, got, 2*test.cells)
+		}
+		if got := lineWidth(v.lines[0], defaultTabWidth); got != 2*test.width {
+			t.Errorf("%q: got width %d, want %d", test.text, got, 2*test.width)
+		}
+	}
+
+	v := newTestView(20, 2)
+	v.Editable = true
+	typeKeys(DefaultEditor, v, "ae\u0301b")
+	if x, _ := v.Cursor(); x != 3 {
+		t.Errorf("got cursor at %d, want 3", x)
+	}
+	typeKeys(DefaultEditor, v, "", editorKey{key: KeyArrowLeft}, editorKey{key: KeyBackspace2})
+	if got := v.Buffer(); got != "ab" {
+		t.Errorf("got %q, want the cluster deleted", got)
+	}
+
+	v = newTestView(20, 2)
+	fmt.Fprint(v, "e\u0301 x e\u0301")
+	if err := v.Search("\u0301", SearchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.search.matches; !reflect.DeepEqual(got, []searchMatch{{y: 0, x: 0, n: 1}, {y: 0, x: 4, n: 1}}) {
+		t.Errorf("got matches %v", got)
+	}
+}
+
+func TestWideCharacterCursor(t *testing.T) {
+	v := newTestView(10, 2)
+	v.Editable = true
+	typeKeys(DefaultEditor, v, "日本語", editorKey{key: KeyArrowLeft})
+
+	if x, _ := v.Cursor(); x != 2 {
+		t.Errorf("got cursor at index %d, want 2", x)
+	}
+	if x, _, _ := v.linesPosOnScreen(v.cx, v.cy); x != 4 {
+		t.Errorf("got cursor at column %d, want 4", x)
+	}
+	for _, c := range []struct{ x, col int }{{0, 0}, {1, 2}, {3, 6}, {5, 8}} {
+		if got := v.BufferXToColumn(c.x, 0); got != c.col {
+			t.Errorf("BufferXToColumn(%d): got %d, want %d", c.x, got, c.col)
+		}
+	}
+	for _, c := range []struct{ col, x int }{{0, 0}, {1, 0}, {3, 1}, {6, 3}, {9, 3}} {
+		if got := v.ColumnToBufferX(c.col, 0); got != c.x {
+			t.Errorf("ColumnToBufferX(%d): got %d, want %d", c.col, got, c.x)
+		}
+	}
+
+	typeKeys(DefaultEditor, v, "x")
+	if got := v.Buffer(); got != "日本x語" {
+		t.Errorf("got %q, want %q", got, "日本x語")
+	}
+
+	// the origin is a column, it scrolls so that the whole character under
+	// the cursor is visible
+	v = newTestView(5, 2)
+	v.Editable = true
+	typeKeys(DefaultEditor, v, "日本語", editorKey{key: KeyArrowLeft})
+	if v.ox != 2 {
+		t.Errorf("got origin %d, want 2", v.ox)
+	}
+
+	// a click moves the cursor to the clicked character of the buffer in
+	// an editable view, and to the clicked cell of the view otherwise
+	for _, editable := range []bool{true, false} {
+		v = newTestView(10, 2)
+		g := v.gui
+		g.views = append(g.views, v)
+		fmt.Fprint(v, "one\n日本語\nthree")
+		v.SetOrigin(0, 1)
+		v.Highlight = true
+		v.Editable = editable
+		if err := g.onKey(&gocuiEvent{Type: eventMouse, Key: MouseLeft, MouseX: 1 + 3, MouseY: 1, Clicks: 1}); err != nil {
+			t.Fatal(err)
+		}
+		wantX, wantY := 1, 1
+		if !editable {
+			wantX, wantY = 3, 0
+		}
+		if x, y := v.Cursor(); x != wantX || y != wantY {
+			t.Errorf("editable %v: got cursor at (%d, %d) after a click, want (%d, %d)", editable, x, y, wantX, wantY)
+		}
+		if v.updateHighlightY(); v.highlightY != 0 {
+			t.Errorf("editable %v: got highlighted line %d, want 0", editable, v.highlightY)
+		}
+	}
+}
+
+func TestTabStops(t *testing.T) {
+	v := newTestView(20, 2)
+	v.Editable = true
+	fmt.Fprint(v, "a\tbcd\tef")
+	v.WriteStyled("\tg", ColorRed, ColorDefault)
+
+	if got := v.Buffer(); got != "a\tbcd\tef\tg" {
+		t.Fatalf("got %q, want the tabs kept in the buffer", got)
+	}
+	if got := lineWidth(v.lines[0], v.tabWidth()); got != 13 {
+		t.Errorf("got width %d, want 13", got)
+	}
+	v.TabWidth = 8
+	for _, c := range []struct{ x, col int }{{1, 1}, {2, 8}, {6, 16}, {9, 24}} {
+		if got := v.BufferXToColumn(c.x, 0); got != c.col {
+			t.Errorf("BufferXToColumn(%d): got %d, want %d", c.x, got, c.col)
+		}
+	}
+	for _, c := range []struct{ col, x int }{{1, 1}, {7, 1}, {8, 2}, {15, 5}} {
+		if got := v.ColumnToBufferX(c.col, 0); got != c.x {
+			t.Errorf("ColumnToBufferX(%d): got %d, want %d", c.col, got, c.x)
+		}
+	}
+
+	// the cursor moves over a tab as one character
+	v.SetCursor(0, 0)
+	typeKeys(DefaultEditor, v, "", editorKey{key: KeyArrowRight}, editorKey{key: KeyArrowRight})
+	if x, _ := v.Cursor(); x != 2 {
+		t.Errorf("got cursor at index %d, want 2", x)
+	}
+	typeKeys(DefaultEditor, v, "", editorKey{key: KeyBackspace2})
+	if got := v.Buffer(); got != "abcd\tef\tg" {
+		t.Errorf("got %q, want the tab deleted", got)
+	}
+
+	// tab stops of continuation lines are counted from the screen
+	v = newTestView(6, 3)
+	v.Wrap = true
+	v.WrapMarker = "> "
+	fmt.Fprint(v, "abcdefgh\tij")
+	if got := v.ViewBufferLines(); !reflect.DeepEqual(got, []string{"abcdef", "> gh", "> \tij"}) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestGutter(t *testing.T) {
+	v := newTestView(20, 3)
+	v.LineNumbers = LineNumbersAbsolute
+	v.SignColumn = true
+	fmt.Fprint(v, "one\ntwo\nthree\nfour")
+	v.SetSign(1, Sign{Text: "●"})
+	v.SetSign(2, Sign{Text: ">>>"})
+
+	if w, _ := v.Size(); w != 20-6 {
+		t.Errorf("got width %d, want %d", w, 20-6)
+	}
+	v.oy = 1
+	gutter := func() (lines []string) {
+		_, maxY := v.Size()
+		for _, y := range v.gutterLines(maxY) {
+			lines = append(lines, lineType(v.gutterCells(y, v.gutterWidth())).String())
+		}
+		return lines
+	}
+	if got, want := gutter(), []string{"●   2 ", ">>  3 ", "    4 "}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	v.LineNumbers = LineNumbersRelative
+	v.SignColumn = false
+	v.SetCursor(0, 2)
+	if got, want := gutter(), []string{"  1 ", "  3 ", "  1 "}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// continuation lines of wrapped lines have no number
+	v = newTestView(8, 4)
+	v.LineNumbers = LineNumbersAbsolute
+	v.Wrap = true
+	fmt.Fprint(v, "abcdefgh\nij")
+	if got, want := gutter(), []string{"  1 ", "    ", "  2 ", "    "}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := v.ViewBufferLines(); !reflect.DeepEqual(got, []string{"abcd", "efgh", "ij"}) {
+		t.Errorf("got view lines %q", got)
+	}
+}
+
+func TestSignsFollowLines(t *testing.T) {
+	v := newTestView(20, 5)
+	v.Editable = true
+	fmt.Fprint(v, "a\nb\nc")
+	v.SetSign(1, Sign{Text: "B"})
+	v.SetSign(2, Sign{Text: "C"})
+	signs := func() map[string]string {
+		got := map[string]string{}
+		for y, s := range v.signs {
+			line, _ := v.Line(y)
+			got[line] = s.Text
+		}
+		return got
+	}
+	want := map[string]string{"b": "B", "c": "C"}
+
+	// a line inserted before "b"
+	v.SetCursor(0, 1)
+	v.EditNewLine()
+	if got := signs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after new line: got %v, want %v", got, want)
+	}
+	v.Undo()
+	if got := signs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after undo: got %v, want %v", got, want)
+	}
+	v.Redo()
+	if got := signs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after redo: got %v, want %v", got, want)
+	}
+
+	// "b" deleted with the line break before it
+	v.deleteRange(bufferPos{1, 0}, bufferPos{1, 2})
+	delete(want, "b")
+	if got := signs(); !reflect.DeepEqual(got, want) || len(v.signs) != 1 {
+		t.Errorf("after delete: got %v, want %v", got, want)
+	}
+
+	// undoing a split keeps the sign on the joined line
+	v = newTestView(20, 5)
+	v.Editable = true
+	fmt.Fprint(v, "ab\nc")
+	v.SetSign(0, Sign{Text: "A"})
+	v.SetSign(1, Sign{Text: "C"})
+	v.SetCursor(1, 0)
+	v.EditNewLine()
+	v.Undo()
+	if got, want := signs(), map[string]string{"ab": "A", "c": "C"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after undoing a split: got %v, want %v", got, want)
+	}
+
+	// evicted lines lose their signs
+	v = newTestView(20, 5)
+	v.MaxLines = 3
+	fmt.Fprint(v, "a\nb\nc")
+	v.SetSign(1, Sign{Text: "B"})
+	v.SetSign(2, Sign{Text: "C"})
+	fmt.Fprint(v, "\nd")
+	if got, want := signs(), map[string]string{"b": "B", "c": "C"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after eviction: got %v, want %v", got, want)
+	}
+	fmt.Fprint(v, "\ne\nf")
+	if len(v.signs) != 0 {
+		t.Errorf("got signs %v after eviction, want none", v.signs)
+	}
+}
@@ -0,0 +1,655 @@
+// Copyright 2021 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"strings"
+	"unicode"
+)
+
+// VimMode is the mode of a VimEditor.
+type VimMode int
+
+// Modes of VimEditor.
+const (
+	VimNormal VimMode = iota
+	VimInsert
+	VimVisual
+	VimVisualLine
+)
+
+// String returns the name of the mode, as displayed by vim in its status
+// line.
+func (m VimMode) String() string {
+	switch m {
+	case VimInsert:
+		return "INSERT"
+	case VimVisual:
+		return "VISUAL"
+	case VimVisualLine:
+		return "VISUAL LINE"
+	}
+	return "NORMAL"
+}
+
+// vimKey is a key press received by a VimEditor, recorded to repeat changes.
+type vimKey struct {
+	key Key
+	ch  rune
+	mod Modifier
+}
+
+// vimMotion is the result of a motion: the new cursor position, and how an
+// operator applies to the text between the cursor and this position.
+type vimMotion struct {
+	pos       bufferPos
+	linewise  bool
+	inclusive bool
+}
+
+// VimEditor is a modal Editor with a subset of the key bindings of vim:
+//
+//	normal mode:  h j k l w b e 0 $ gg G, preceded by an optional count
+//	              x X D C p P     delete, change and put text
+//	              u Ctrl+R        undo and redo
+//	              .               repeat the last change
+//	              i a I A o O     enter insert mode
+//	              v V             enter visual mode
+//	operators:    d c y, followed by a motion, the text objects iw and aw, or
+//	              repeated to apply to whole lines (dd, cc, yy)
+//	visual mode:  motions extend the selection, d x c y apply to it
+//	insert mode:  the keys of DefaultEditor, Esc returns to normal mode
+//
+// A VimEditor holds the state of a single view: each view needs its own
+// editor.
+type VimEditor struct {
+	// OnModeChange, if not nil, is called when the mode changes, e.g. to
+	// display it in a status line.
+	OnModeChange func(v *View, mode VimMode)
+
+	mode VimMode
+
+	// command being typed in normal and visual modes
+	count   int
+	op      rune
+	opCount int
+	prefix  rune // 'g', or 'i' and 'a' before a text object
+
+	// register holds the last deleted or yanked text
+	register         string
+	registerLinewise bool
+
+	// start of the selection in visual modes
+	visualStart bufferPos
+
+	// keys of the command being typed, and of the last change for "."
+	// recording is true in insert mode if the command entering it is a
+	// change to record
+	keys       []vimKey
+	lastChange []vimKey
+	recording  bool
+	replaying  bool
+}
+
+// NewVimEditor returns a VimEditor in normal mode.
+func NewVimEditor() *VimEditor {
+	return &VimEditor{}
+}
+
+// Mode returns the current mode of the editor.
+func (e *VimEditor) Mode() VimMode {
+	return e.mode
+}
+
+// Edit handles a key press in an editable view. It implements the Editor
+// interface.
+func (e *VimEditor) Edit(v *View, key Key, ch rune, mod Modifier) {
+	if !e.replaying {
+		e.keys = append(e.keys, vimKey{key, ch, mod})
+	}
+	if e.mode == VimInsert {
+		e.editInsert(v, key, ch, mod)
+		return
+	}
+	e.editNormal(v, key, ch)
+}
+
+// setMode changes the mode and calls OnModeChange.
+func (e *VimEditor) setMode(v *View, mode VimMode) {
+	if mode == e.mode {
+		return
+	}
+	visual := e.mode == VimVisual || e.mode == VimVisualLine
+	e.mode = mode
+	switch {
+	case mode == VimVisual || mode == VimVisualLine:
+		if !visual {
+			e.visualStart = v.cursorPos()
+		}
+		e.updateVisual(v)
+	case visual:
+		v.ClearSelection()
+	}
+	if e.OnModeChange != nil {
+		e.OnModeChange(v, mode)
+	}
+}
+
+// done ends the current command. A change is recorded for "." unless it
+// entered insert mode, in which case it is recorded when leaving it.
+func (e *VimEditor) done(change bool) {
+	if e.mode == VimInsert {
+		e.recording = change
+	} else {
+		if change && !e.replaying {
+			e.lastChange = e.keys
+		}
+		e.keys = nil
+	}
+	e.count, e.op, e.opCount, e.prefix = 0, 0, 0, 0
+}
+
+func (e *VimEditor) editInsert(v *View, key Key, ch rune, mod Modifier) {
+	if key != KeyEsc {
+		simpleEditor(v, key, ch, mod)
+		return
+	}
+	if v.cx > 0 {
+		v.MoveCursor(-1, 0)
+	}
+	e.setMode(v, VimNormal)
+	if e.recording && !e.replaying {
+		e.lastChange = e.keys
+	}
+	e.keys, e.recording = nil, false
+}
+
+func (e *VimEditor) editNormal(v *View, key Key, ch rune) {
+	switch key {
+	case KeyEsc:
+		e.setMode(v, VimNormal)
+		e.done(false)
+		return
+	case KeyCtrlR:
+		v.Redo()
+		e.clampCursor(v)
+		e.done(false)
+		return
+	case KeyArrowLeft:
+		ch = 'h'
+	case KeyArrowDown:
+		ch = 'j'
+	case KeyArrowUp:
+		ch = 'k'
+	case KeyArrowRight:
+		ch = 'l'
+	}
+	if ch == 0 {
+		e.done(false)
+		return
+	}
+
+	if ch >= '1' && ch <= '9' || ch == '0' && e.count > 0 {
+		e.count = e.count*10 + int(ch-'0')
+		return
+	}
+	count := max(e.opCount, 1) * max(e.count, 1)
+
+	switch e.prefix {
+	case 'g':
+		e.prefix = 0
+		if ch != 'g' {
+			e.done(false)
+			return
+		}
+		line := 0
+		if e.count > 0 || e.opCount > 0 {
+			line = min(count-1, max(len(v.lines)-1, 0))
+		}
+		e.applyMotion(v, vimMotion{pos: v.firstNonBlank(line), linewise: true})
+		return
+	case 'i', 'a':
+		if ch != 'w' {
+			e.done(false)
+			return
+		}
+		start, end := v.wordObject(v.cursorPos(), e.prefix == 'a')
+		op := e.op
+		e.applyRange(v, start, end, false)
+		e.done(op != 'y')
+		return
+	}
+
+	switch {
+	case ch == 'g':
+		e.prefix = 'g'
+		return
+	case (ch == 'i' || ch == 'a') && e.op != 0:
+		e.prefix = ch
+		return
+	case e.mode == VimVisual || e.mode == VimVisualLine:
+		if e.editVisual(v, ch) {
+			return
+		}
+	case e.op == 0:
+		if e.command(v, ch, count) {
+			return
+		}
+	case ch == e.op:
+		// dd, cc and yy apply to count lines
+		op := e.op
+		last := min(v.cy+count-1, max(len(v.lines)-1, 0))
+		e.applyRange(v, bufferPos{0, v.cy}, bufferPos{0, last}, true)
+		e.done(op != 'y')
+		return
+	}
+
+	m, ok := e.motion(v, ch, count)
+	if !ok {
+		e.done(false)
+		return
+	}
+	e.applyMotion(v, m)
+}
+
+// command runs the normal mode commands which are not motions. It returns
+// false if ch is not one of them.
+func (e *VimEditor) command(v *View, ch rune, count int) bool {
+	switch ch {
+	case 'd', 'c', 'y':
+		e.op, e.opCount, e.count = ch, e.count, 0
+		return true
+	case 'x', 'X', 'D', 'C':
+		e.op = 'd'
+		if ch == 'C' {
+			e.op = 'c'
+		}
+		m, _ := e.motion(v, map[rune]rune{'x': 'l', 'X': 'h', 'D': '$', 'C': '$'}[ch], count)
+		e.applyMotion(v, m)
+		return true
+	case 'p', 'P':
+		for i := 0; i < count; i++ {
+			e.put(v, ch == 'p')
+		}
+	case '.':
+		e.repeat(v, count)
+		return true
+	case 'u':
+		for i := 0; i < count; i++ {
+			v.Undo()
+		}
+		e.clampCursor(v)
+	case 'i':
+		e.setMode(v, VimInsert)
+	case 'a':
+		if v.cx < v.lineLen(v.cy) {
+			v.MoveCursor(1, 0)
+		}
+		e.setMode(v, VimInsert)
+	case 'I':
+		v.moveCursorTo(v.firstNonBlank(v.cy))
+		e.setMode(v, VimInsert)
+	case 'A':
+		v.moveCursorTo(bufferPos{v.lineLen(v.cy), v.cy})
+		e.setMode(v, VimInsert)
+	case 'o':
+		v.moveCursorTo(bufferPos{v.lineLen(v.cy), v.cy})
+		v.EditNewLine()
+		e.setMode(v, VimInsert)
+	case 'O':
+		v.moveCursorTo(bufferPos{0, v.cy})
+		v.EditNewLine()
+		v.MoveCursor(0, -1)
+		e.setMode(v, VimInsert)
+	case 'v':
+		e.setMode(v, VimVisual)
+	case 'V':
+		e.setMode(v, VimVisualLine)
+	default:
+		return false
+	}
+	e.done(ch == 'p' || ch == 'P' || e.mode == VimInsert)
+	return true
+}
+
+// editVisual runs the visual mode commands which are not motions. It
+// returns false if ch is not one of them.
+func (e *VimEditor) editVisual(v *View, ch rune) bool {
+	switch ch {
+	case 'v', 'V':
+		mode := VimVisual
+		if ch == 'V' {
+			mode = VimVisualLine
+		}
+		if mode == e.mode {
+			mode = VimNormal
+		}
+		e.setMode(v, mode)
+	case 'd', 'x', 'c', 'y':
+		e.op = ch
+		if ch == 'x' {
+			e.op = 'd'
+		}
+		linewise := e.mode == VimVisualLine
+		start, end := e.visualStart, v.cursorPos()
+		if end.before(start) {
+			start, end = end, start
+		}
+		e.setMode(v, VimNormal)
+		if !linewise {
+			end = v.afterPos(end)
+		}
+		e.applyRange(v, start, end, linewise)
+	default:
+		return false
+	}
+	// changes made in visual mode are not repeated
+	e.done(false)
+	return true
+}
+
+// motion returns the position the cursor moves to with a motion key, and
+// false if ch is not a motion.
+func (e *VimEditor) motion(v *View, ch rune, count int) (vimMotion, bool) {
+	p := v.cursorPos()
+	switch ch {
+	case 'h':
+		p.x = max(p.x-count, 0)
+	case 'l':
+		p.x = min(p.x+count, v.lineLen(p.y))
+	case 'j', 'k':
+		if ch == 'k' {
+			count = -count
+		}
+		p.y = max(0, min(p.y+count, len(v.lines)-1))
+		p.x = min(p.x, v.lineLen(p.y))
+		return vimMotion{pos: p, linewise: true}, true
+	case 'w':
+		if e.op == 'c' && vimClass(v.charAt(p)) != 0 {
+			// cw changes to the end of the word, like ce
+			return e.motion(v, 'e', count)
+		}
+		for i := 0; i < count; i++ {
+			p = v.nextWordStart(p)
+		}
+		if e.op != 0 && p.x == 0 && p.y > v.cy {
+			// an operator doesn't delete the line break before the next
+			// word
+			p = bufferPos{v.lineLen(p.y - 1), p.y - 1}
+		}
+	case 'b':
+		for i := 0; i < count; i++ {
+			p = v.prevWordStart(p)
+		}
+	case 'e':
+		for i := 0; i < count; i++ {
+			p = v.nextWordEnd(p)
+		}
+		return vimMotion{pos: p, inclusive: true}, true
+	case '0':
+		p.x = 0
+	case '$':
+		p.y = min(p.y+count-1, max(len(v.lines)-1, 0))
+		p.x = v.lineLen(p.y)
+	case 'G':
+		line := len(v.lines) - 1
+		if e.count > 0 || e.opCount > 0 {
+			line = min(count-1, line)
+		}
+		return vimMotion{pos: v.firstNonBlank(max(line, 0)), linewise: true}, true
+	default:
+		return vimMotion{}, false
+	}
+	return vimMotion{pos: p}, true
+}
+
+// applyMotion moves the cursor, or applies the pending operator to the text
+// between the cursor and the position of the motion.
+func (e *VimEditor) applyMotion(v *View, m vimMotion) {
+	op := e.op
+	if op == 0 {
+		v.moveCursorTo(m.pos)
+		e.clampCursor(v)
+		if e.mode == VimVisual || e.mode == VimVisualLine {
+			e.updateVisual(v)
+		}
+		e.done(false)
+		return
+	}
+
+	start, end := v.cursorPos(), m.pos
+	if end.before(start) {
+		start, end = end, start
+	}
+	if m.inclusive {
+		end = v.afterPos(end)
+	}
+	e.applyRange(v, start, end, m.linewise)
+	e.done(op != 'y')
+}
+
+// applyRange applies the pending operator to the text from start to end
+// (excluded), or to the lines from start to end when linewise is true.
+func (e *VimEditor) applyRange(v *View, start, end bufferPos, linewise bool) {
+	if linewise {
+		e.applyLines(v, start.y, end.y)
+	} else {
+		e.register, e.registerLinewise = v.textRange(start, end), false
+		if e.op == 'y' {
+			v.moveCursorTo(start)
+		} else {
+			v.edit(editOther, start.y, end.y, func() {
+				v.deleteRange(start, end)
+			})
+		}
+	}
+
+	if e.op == 'c' {
+		e.setMode(v, VimInsert)
+	} else {
+		e.clampCursor(v)
+	}
+}
+
+// applyLines applies the pending operator to the lines from y0 to y1.
+func (e *VimEditor) applyLines(v *View, y0, y1 int) {
+	if len(v.lines) == 0 {
+		return
+	}
+	y1 = min(y1, len(v.lines)-1)
+	lines := make([]string, 0, y1-y0+1)
+	for y := y0; y <= y1; y++ {
+		lines = append(lines, v.textRange(bufferPos{0, y}, bufferPos{v.lineLen(y), y}))
+	}
+	e.register, e.registerLinewise = strings.Join(lines, "\n"), true
+
+	switch e.op {
+	case 'y':
+		v.moveCursorTo(bufferPos{min(v.cx, v.lineLen(y0)), y0})
+	case 'c':
+		v.edit(editOther, y0, y1, func() {
+			v.deleteRange(bufferPos{0, y0}, bufferPos{v.lineLen(y1), y1})
+		})
+	case 'd':
+		v.edit(editOther, y0-1, y1+1, func() {
+			switch {
+			case y1+1 < len(v.lines):
+				v.deleteRange(bufferPos{0, y0}, bufferPos{0, y1 + 1})
+			case y0 > 0:
+				v.deleteRange(bufferPos{v.lineLen(y0 - 1), y0 - 1}, bufferPos{v.lineLen(y1), y1})
+			default:
+				v.deleteRange(bufferPos{0, y0}, bufferPos{v.lineLen(y1), y1})
+			}
+			v.moveCursorTo(v.firstNonBlank(min(y0, len(v.lines)-1)))
+		})
+	}
+}
+
+// put inserts the register after the cursor, or before it. Lines are put
+// below or above the line of the cursor.
+func (e *VimEditor) put(v *View, after bool) {
+	if e.register == "" {
+		return
+	}
+	v.edit(editOther, v.cy, v.cy, func() {
+		if e.registerLinewise {
+			y := v.cy
+			if after {
+				v.moveCursorTo(bufferPos{v.lineLen(y), y})
+				v.insertText("\n" + e.register)
+				y++
+			} else {
+				v.moveCursorTo(bufferPos{0, y})
+				v.insertText(e.register + "\n")
+			}
+			v.moveCursorTo(v.firstNonBlank(y))
+			return
+		}
+		if after && v.cx < v.lineLen(v.cy) {
+			v.MoveCursor(1, 0)
+		}
+		v.insertText(e.register)
+		if v.cx > 0 {
+			v.MoveCursor(-1, 0)
+		}
+	})
+}
+
+// repeat replays the keys of the last change count times.
+func (e *VimEditor) repeat(v *View, count int) {
+	keys := e.lastChange
+	e.done(false)
+	e.replaying = true
+	for i := 0; i < count; i++ {
+		for _, k := range keys {
+			e.Edit(v, k.key, k.ch, k.mod)
+		}
+	}
+	e.replaying = false
+}
+
+// updateVisual selects the text between the start of the visual mode and
+// the cursor.
+func (e *VimEditor) updateVisual(v *View) {
+	start, end := e.visualStart, v.cursorPos()
+	if end.before(start) {
+		start, end = end, start
+	}
+	if e.mode == VimVisualLine {
+		start.x, end.x = 0, v.lineLen(end.y)
+	} else {
+		end.x++
+	}
+	v.selection = &bufferRange{start: start, end: end}
+	v.tainted = true
+}
+
+// clampCursor keeps the cursor on a character of the line, as in normal
+// mode it can't be after the end of the line.
+func (e *VimEditor) clampCursor(v *View) {
+	if n := v.lineLen(v.cy); n > 0 && v.cx >= n {
+		v.moveCursorTo(bufferPos{n - 1, v.cy})
+	}
+}
+
+// afterPos returns the position following the character at p, which is the
+// start of the next line at the end of a line.
+func (v *View) afterPos(p bufferPos) bufferPos {
+	next, _ := v.nextPos(p)
+	return next
+}
+
+// vimClass returns the class of a rune for the word motions: blanks, word
+// characters and other characters.
+func vimClass(r rune) int {
+	switch {
+	case r == 0 || unicode.IsSpace(r):
+		return 0
+	case isWordChar(r):
+		return 1
+	}
+	return 2
+}
+
+// nextWordStart returns the start of the word after p (the w motion).
+func (v *View) nextWordStart(p bufferPos) bufferPos {
+	if c := vimClass(v.charAt(p)); c != 0 {
+		p = v.skipForward(p, func(r rune) bool { return vimClass(r) == c })
+	}
+	return v.skipForward(p, unicode.IsSpace)
+}
+
+// prevWordStart returns the start of the word before p (the b motion).
+func (v *View) prevWordStart(p bufferPos) bufferPos {
+	p = v.skipBackward(p, unicode.IsSpace)
+	prev, ok := v.prevPos(p)
+	if !ok {
+		return p
+	}
+	c := vimClass(v.charAt(prev))
+	return v.skipBackward(p, func(r rune) bool { return vimClass(r) == c })
+}
+
+// nextWordEnd returns the end of the word after p (the e motion).
+func (v *View) nextWordEnd(p bufferPos) bufferPos {
+	next, ok := v.nextPos(p)
+	if !ok {
+		return p
+	}
+	next = v.skipForward(next, unicode.IsSpace)
+	c := vimClass(v.charAt(next))
+	if c == 0 {
+		return p
+	}
+	for {
+		n, ok := v.nextPos(next)
+		if !ok || vimClass(v.charAt(n)) != c {
+			return next
+		}
+		next = n
+	}
+}
+
+// wordObject returns the range of the word at p (the iw text object), with
+// the blanks after it, or before it if there is none, when around is true
+// (the aw text object).
+func (v *View) wordObject(p bufferPos, around bool) (bufferPos, bufferPos) {
+	n := v.lineLen(p.y)
+	if n == 0 {
+		return p, p
+	}
+	line := v.lines[p.y]
+	x := min(p.x, n-1)
+	c := vimClass(line[x].chr)
+	start, end := x, x+1
+	for start > 0 && vimClass(line[start-1].chr) == c {
+		start--
+	}
+	for end < n && vimClass(line[end].chr) == c {
+		end++
+	}
+	if around && c != 0 {
+		if end < n && vimClass(line[end].chr) == 0 {
+			for end < n && vimClass(line[end].chr) == 0 {
+				end++
+			}
+		} else {
+			for start > 0 && vimClass(line[start-1].chr) == 0 {
+				start--
+			}
+		}
+	}
+	return bufferPos{start, p.y}, bufferPos{end, p.y}
+}
+
+// firstNonBlank returns the position of the first non blank character of the
+// line y.
+func (v *View) firstNonBlank(y int) bufferPos {
+	x := 0
+	for x < v.lineLen(y) && unicode.IsSpace(v.lines[y][x].chr) {
+		x++
+	}
+	return bufferPos{x, y}
+}